@@ -1,7 +1,6 @@
 package jsonez
 
 import (
-	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -41,6 +40,10 @@ func (g *GoJSON) AddEntryToObject(key string, entry *GoJSON) {
 func (g *GoJSON) DelEntryFromObject(key string) error {
 	var cur, prev *GoJSON
 
+	if g.Child == nil {
+		return pathError([]string{key}, 1, ErrPathNotFound)
+	}
+
 	/*
 	 * For first element, special processing needs
 	 * to be done
@@ -48,6 +51,9 @@ func (g *GoJSON) DelEntryFromObject(key string) error {
 	if g.Child.Key == key {
 		cur = g.Child
 		g.Child = cur.Next
+		if g.Child != nil {
+			g.Child.Prev = nil
+		}
 		cur = nil
 		return nil
 	}
@@ -57,8 +63,7 @@ func (g *GoJSON) DelEntryFromObject(key string) error {
 
 	for {
 		if cur == nil {
-			errorStr := fmt.Sprintf("%s: Child object with key %s not found", funcName(), key)
-			return errors.New(errorStr)
+			return pathError([]string{key}, 1, ErrPathNotFound)
 		} else if cur.Key == key {
 			/*
 			 * Check for last element in the list
@@ -110,10 +115,14 @@ func (g *GoJSON) GetArraySize() int {
  */
 func (g *GoJSON) GetArrayElemByIndex(loc int) (*GoJSON, error) {
 	var child *GoJSON
+	var index int = loc
 
 	if g.Jsontype != JSON_ARRAY {
-		errorStr := fmt.Sprintf("%s: Parsing Error", funcName())
-		return nil, errors.New(errorStr)
+		return nil, typeError(nil, JSON_ARRAY, g.Jsontype)
+	}
+
+	if loc < 0 {
+		return nil, &IndexError{Index: index, Size: g.GetArraySize()}
 	}
 
 	child = g.Child
@@ -127,60 +136,77 @@ func (g *GoJSON) GetArrayElemByIndex(loc int) (*GoJSON, error) {
 		}
 	}
 
+	if child == nil {
+		return nil, &IndexError{Index: index, Size: g.GetArraySize()}
+	}
+
 	return child, nil
 }
 
 /**
- * Method to get an array entry based on the value of the element
+ * Method to get an array entry based on the value of the element.
+ * Only elements of type Jsontype match. Integers of any width are
+ * accepted for JSON_INT and JSON_UINT
  */
 func (g *GoJSON) GetArrayEntry(val interface{}, Jsontype int) (*GoJSON, error) {
-	var child *GoJSON
-	var jtype string
+	var ok bool
 
 	if g.Jsontype != JSON_ARRAY {
-		errorStr := fmt.Sprintf("%s: Parsing Error", funcName())
-		return nil, errors.New(errorStr)
+		return nil, typeError(nil, JSON_ARRAY, g.Jsontype)
 	}
 
-	child = g.Child
+	/*
+	 * Convert the value to the field compared for Jsontype
+	 */
+	want := GoJSON{Jsontype: Jsontype}
+	v := reflect.ValueOf(val)
+
+	switch Jsontype {
+	case JSON_INT:
+		want.Valint, ok = intValue(v)
+	case JSON_UINT:
+		want.Valuint, ok = uintValue(v)
+	case JSON_DOUBLE:
+		if ok = v.Kind() == reflect.Float64 || v.Kind() == reflect.Float32; ok {
+			want.Valdouble = v.Float()
+		}
+	case JSON_BOOL:
+		if ok = v.Kind() == reflect.Bool; ok {
+			want.Valbool = v.Bool()
+		}
+	case JSON_STRING:
+		if ok = v.Kind() == reflect.String; ok {
+			want.Valstr = v.String()
+		}
+	}
+
+	if ok {
+		for child := g.Child; child != nil; child = child.Next {
+			if child.Jsontype != Jsontype {
+				continue
+			}
 
-	for {
-		if child != nil {
 			switch Jsontype {
 			case JSON_INT:
-				if child.Valint == int64(val.(int)) {
-					return child, nil
-				}
-				jtype = "JSON_INT"
+				ok = child.Valint == want.Valint
 			case JSON_UINT:
-				if child.Valuint == uint64(val.(uint)) {
-					return child, nil
-				}
-				jtype = "JSON_UINT"
+				ok = child.Valuint == want.Valuint
 			case JSON_DOUBLE:
-				if child.Valdouble == val.(float64) {
-					return child, nil
-				}
-				jtype = "JSON_DOUBLE"
+				ok = child.Valdouble == want.Valdouble
 			case JSON_BOOL:
-				if child.Valbool == val.(bool) {
-					return child, nil
-				}
-				jtype = "JSON_BOOL"
+				ok = child.Valbool == want.Valbool
 			case JSON_STRING:
-				if child.Valstr == val.(string) {
-					return child, nil
-				}
-				jtype = "JSON_STRING"
+				ok = child.Valstr == want.Valstr
+			}
 
+			if ok {
+				return child, nil
 			}
-			child = child.Next
-		} else {
-			errorStr := fmt.Sprintf("%s: Value not found for type %s",
-				funcName(), jtype)
-			return nil, errors.New(errorStr)
 		}
 	}
+
+	return nil, fmt.Errorf("jsonez: %w for type %s",
+		ErrValueNotFound, typeName(Jsontype))
 }
 
 /**
@@ -215,14 +241,16 @@ func (g *GoJSON) DelIndexFromArray(index int) error {
 	var size int = g.GetArraySize()
 	var i int = 1
 
-	if index == 0 {
+	if index < 0 || index >= size {
+		return &IndexError{Index: index, Size: size}
+	} else if index == 0 {
 		cur = g.Child
 		g.Child = cur.Next
+		if g.Child != nil {
+			g.Child.Prev = nil
+		}
 		cur = nil
 		return nil
-	} else if index >= size {
-		errorStr := fmt.Sprintf("%s: Index exceeds array size of %d", funcName(), size)
-		return errors.New(errorStr)
 	} else {
 		prev = g.Child
 		cur = prev.Next
@@ -261,7 +289,19 @@ func (g *GoJSON) DelArrayEntry(val interface{}, Jsontype int) error {
 		return err
 	}
 
-	elem.Prev.Next = elem.Next
+	/*
+	 * The first element has no Prev
+	 */
+	if elem.Prev == nil {
+		g.Child = elem.Next
+	} else {
+		elem.Prev.Next = elem.Next
+	}
+
+	if elem.Next != nil {
+		elem.Next.Prev = elem.Prev
+	}
+
 	elem = nil
 
 	return nil
@@ -273,12 +313,11 @@ func (g *GoJSON) DelArrayEntry(val interface{}, Jsontype int) error {
  */
 func (g *GoJSON) Get(keys ...string) (*GoJSON, error) {
 	var cur *GoJSON = g
-	for _, key := range keys {
+	for i, key := range keys {
 		cur = cur.GetObjectEntry(key)
 
 		if cur == nil {
-			errorStr := fmt.Sprintf("%s: Path not found", funcName())
-			return nil, errors.New(errorStr)
+			return nil, pathError(keys, i+1, ErrPathNotFound)
 		}
 	}
 
@@ -290,19 +329,14 @@ func (g *GoJSON) Get(keys ...string) (*GoJSON, error) {
  * get the integer value of the key if exists
  */
 func (g *GoJSON) GetIntVal(keys ...string) (int64, error) {
-	var cur *GoJSON = g
-	for _, key := range keys {
-		cur = cur.GetObjectEntry(key)
+	cur, err := g.Get(keys...)
 
-		if cur == nil {
-			errorStr := fmt.Sprintf("%s: Path not found", funcName())
-			return 0, errors.New(errorStr)
-		}
+	if err != nil {
+		return 0, err
 	}
 
 	if cur.Jsontype != JSON_INT {
-		errorStr := fmt.Sprintf("%s: key %s is not of type int", funcName(), cur.Key)
-		return 0, errors.New(errorStr)
+		return 0, typeError(keys, JSON_INT, cur.Jsontype)
 	}
 
 	return cur.Valint, nil
@@ -313,19 +347,14 @@ func (g *GoJSON) GetIntVal(keys ...string) (int64, error) {
  * get the unsigned integer value of the key if exists
  */
 func (g *GoJSON) GetUIntVal(keys ...string) (uint64, error) {
-	var cur *GoJSON = g
-	for _, key := range keys {
-		cur = cur.GetObjectEntry(key)
+	cur, err := g.Get(keys...)
 
-		if cur == nil {
-			errorStr := fmt.Sprintf("%s: Path not found", funcName())
-			return 0, errors.New(errorStr)
-		}
+	if err != nil {
+		return 0, err
 	}
 
 	if cur.Jsontype != JSON_UINT {
-		errorStr := fmt.Sprintf("%s: key %s is not of type uint", funcName(), cur.Key)
-		return 0, errors.New(errorStr)
+		return 0, typeError(keys, JSON_UINT, cur.Jsontype)
 	}
 
 	return cur.Valuint, nil
@@ -333,25 +362,27 @@ func (g *GoJSON) GetUIntVal(keys ...string) (uint64, error) {
 
 /**
  * Functions to query the tree based on a path and
- * get the double value of the key if exists
+ * get the double value of the key if exists. Integers
+ * are converted, since a whole double such as 2.0 is
+ * printed as 2 and parses back as an integer
  */
 func (g *GoJSON) GetDoubleVal(keys ...string) (float64, error) {
-	var cur *GoJSON = g
-	for _, key := range keys {
-		cur = cur.GetObjectEntry(key)
+	cur, err := g.Get(keys...)
 
-		if cur == nil {
-			errorStr := fmt.Sprintf("%s: Path not found", funcName())
-			return 0, errors.New(errorStr)
-		}
+	if err != nil {
+		return 0, err
 	}
 
-	if cur.Jsontype != JSON_DOUBLE {
-		errorStr := fmt.Sprintf("%s: key %s is not of type double", funcName(), cur.Key)
-		return 0, errors.New(errorStr)
+	switch cur.Jsontype {
+	case JSON_DOUBLE:
+		return cur.Valdouble, nil
+	case JSON_INT:
+		return float64(cur.Valint), nil
+	case JSON_UINT:
+		return float64(cur.Valuint), nil
 	}
 
-	return cur.Valdouble, nil
+	return 0, typeError(keys, JSON_DOUBLE, cur.Jsontype)
 }
 
 /**
@@ -359,19 +390,14 @@ func (g *GoJSON) GetDoubleVal(keys ...string) (float64, error) {
  * get the bool value of the key if exists
  */
 func (g *GoJSON) GetBoolVal(keys ...string) (bool, error) {
-	var cur *GoJSON = g
-	for _, key := range keys {
-		cur = cur.GetObjectEntry(key)
+	cur, err := g.Get(keys...)
 
-		if cur == nil {
-			errorStr := fmt.Sprintf("%s: Path not found", funcName())
-			return false, errors.New(errorStr)
-		}
+	if err != nil {
+		return false, err
 	}
 
 	if cur.Jsontype != JSON_BOOL {
-		errorStr := fmt.Sprintf("%s: key %s is not of type double", funcName(), cur.Key)
-		return false, errors.New(errorStr)
+		return false, typeError(keys, JSON_BOOL, cur.Jsontype)
 	}
 
 	return cur.Valbool, nil
@@ -382,19 +408,14 @@ func (g *GoJSON) GetBoolVal(keys ...string) (bool, error) {
  * get the string value of the key if exists
  */
 func (g *GoJSON) GetStringVal(keys ...string) (string, error) {
-	var cur *GoJSON = g
-	for _, key := range keys {
-		cur = cur.GetObjectEntry(key)
+	cur, err := g.Get(keys...)
 
-		if cur == nil {
-			errorStr := fmt.Sprintf("%s: Path not found", funcName())
-			return "", errors.New(errorStr)
-		}
+	if err != nil {
+		return "", err
 	}
 
 	if cur.Jsontype != JSON_STRING {
-		errorStr := fmt.Sprintf("%s: key %s is not of type double", funcName(), cur.Key)
-		return "", errors.New(errorStr)
+		return "", typeError(keys, JSON_STRING, cur.Jsontype)
 	}
 
 	return cur.Valstr, nil
@@ -410,8 +431,13 @@ func (g *GoJSON) AddVal(val interface{}, paths ...string) error {
 	var key string
 
 	prev = g
+	n := 0
 
 	for i, k := range paths {
+		if prev.Jsontype != JSON_OBJECT {
+			return typeError(paths[:i], JSON_OBJECT, prev.Jsontype)
+		}
+
 		cur = prev.GetObjectEntry(k)
 		key = k
 
@@ -420,45 +446,26 @@ func (g *GoJSON) AddVal(val interface{}, paths ...string) error {
 				cur = new(GoJSON)
 				break
 			} else {
-				errorStr := fmt.Sprintf("%s: Path %s not found", funcName(), k)
-				return errors.New(errorStr)
+				return pathError(paths, i+1, ErrPathNotFound)
 			}
 		}
 		prev = cur
+		n = i + 1
+	}
+
+	if prev.Jsontype != JSON_OBJECT {
+		return typeError(paths[:n], JSON_OBJECT, prev.Jsontype)
 	}
 
 	/*
-	 * Get the json type of the value to be added
+	 * Create the value to be added
 	 */
-	t, err := resolveInterface(val)
+	cur, err := allocValue(val)
 	if err != nil {
 		return err
 	}
 
-	switch t {
-	case JSON_INT:
-		v := reflect.ValueOf(val)
-		if val.(int) < 0 {
-			cur = AllocNumber(float64(v.Int()), JSON_INT)
-		} else {
-			cur = AllocNumber(float64(uint64(v.Int())), JSON_UINT)
-		}
-
-		prev.AddEntryToObject(key, cur)
-	case JSON_UINT:
-		v := reflect.ValueOf(val)
-		cur = AllocNumber(float64(v.Uint()), JSON_UINT)
-		prev.AddEntryToObject(key, cur)
-	case JSON_DOUBLE:
-		cur = AllocNumber(val.(float64), JSON_DOUBLE)
-		prev.AddEntryToObject(key, cur)
-	case JSON_BOOL:
-		cur = AllocBool(val.(bool))
-		prev.AddEntryToObject(key, cur)
-	case JSON_STRING:
-		cur = AllocString(val.(string))
-		prev.AddEntryToObject(key, cur)
-	}
+	prev.AddEntryToObject(key, cur)
 
 	return nil
 }
@@ -482,8 +489,7 @@ func (g *GoJSON) AddToArray(val interface{}, paths ...string) error {
 			if i == size-1 {
 				break
 			} else {
-				errorStr := fmt.Sprintf("%s: Path %s not found", funcName(), k)
-				return errors.New(errorStr)
+				return pathError(paths, i+1, ErrPathNotFound)
 			}
 		} else {
 			prev = cur
@@ -497,42 +503,19 @@ func (g *GoJSON) AddToArray(val interface{}, paths ...string) error {
 		arr = cur
 
 		if arr.Jsontype != JSON_ARRAY {
-			errorStr := fmt.Sprintf("%s: GoJSON object with key %s is not of type array", funcName(), key)
-			return errors.New(errorStr)
+			return typeError(paths, JSON_ARRAY, arr.Jsontype)
 		}
 	}
 
 	/*
-	 * Get the json type of the value to be added
+	 * Create the value to be added
 	 */
-	t, err := resolveInterface(val)
+	cur, err := allocValue(val)
 	if err != nil {
 		return err
 	}
 
-	switch t {
-	case JSON_INT:
-		v := reflect.ValueOf(val)
-		if val.(int) < 0 {
-			cur = AllocNumber(float64(v.Int()), JSON_INT)
-		} else {
-			cur = AllocNumber(float64(uint64(v.Int())), JSON_UINT)
-		}
-		arr.AddEntryToArray(cur)
-	case JSON_UINT:
-		v := reflect.ValueOf(val)
-		cur = AllocNumber(float64(v.Uint()), JSON_UINT)
-		arr.AddEntryToArray(cur)
-	case JSON_DOUBLE:
-		cur = AllocNumber(val.(float64), JSON_DOUBLE)
-		arr.AddEntryToArray(cur)
-	case JSON_BOOL:
-		cur = AllocBool(val.(bool))
-		arr.AddEntryToArray(cur)
-	case JSON_STRING:
-		cur = AllocString(val.(string))
-		arr.AddEntryToArray(cur)
-	}
+	arr.AddEntryToArray(cur)
 
 	return nil
 }
@@ -547,12 +530,15 @@ func (g *GoJSON) DelVal(paths ...string) error {
 	var key string
 	size := len(paths)
 
+	if size == 0 {
+		return pathError(paths, 0, ErrPathNotFound)
+	}
+
 	for i, k := range paths {
 		cur = prev.GetObjectEntry(k)
 
 		if cur == nil {
-			errorStr := fmt.Sprintf("%s: Path %s not found", funcName(), k)
-			return errors.New(errorStr)
+			return pathError(paths, i+1, ErrPathNotFound)
 		} else if i == size-1 && cur.Key == k {
 			key = k
 			break
@@ -562,8 +548,7 @@ func (g *GoJSON) DelVal(paths ...string) error {
 	}
 
 	if prev.Jsontype != JSON_OBJECT {
-		errorStr := fmt.Sprintf("%s: %s is not a json object", funcName(), prev.Key)
-		return errors.New(errorStr)
+		return typeError(paths[:len(paths)-1], JSON_OBJECT, prev.Jsontype)
 	}
 
 	return prev.DelEntryFromObject(key)
@@ -578,12 +563,15 @@ func (g *GoJSON) DelFromArray(val interface{}, paths ...string) error {
 	prev = g
 	size := len(paths)
 
+	if size == 0 {
+		return pathError(paths, 0, ErrPathNotFound)
+	}
+
 	for i, k := range paths {
 		cur = prev.GetObjectEntry(k)
 
 		if cur == nil {
-			errorStr := fmt.Sprintf("%s: Path %s not found", funcName(), k)
-			return errors.New(errorStr)
+			return pathError(paths, i+1, ErrPathNotFound)
 		} else if i == size-1 && cur.Key == k {
 			break
 		} else {
@@ -592,8 +580,7 @@ func (g *GoJSON) DelFromArray(val interface{}, paths ...string) error {
 	}
 
 	if cur.Jsontype != JSON_ARRAY {
-		errorStr := fmt.Sprintf("%s: %s is not a json array", funcName(), cur.Key)
-		return errors.New(errorStr)
+		return typeError(paths, JSON_ARRAY, cur.Jsontype)
 	}
 
 	/*
@@ -606,10 +593,10 @@ func (g *GoJSON) DelFromArray(val interface{}, paths ...string) error {
 
 	switch t {
 	case JSON_INT:
-		if val.(int) < 0 {
-			return cur.DelArrayEntry(val, JSON_INT)
+		if v := reflect.ValueOf(val); v.Int() < 0 {
+			return cur.DelArrayEntry(v.Int(), JSON_INT)
 		} else {
-			return cur.DelArrayEntry(uint(val.(int)), JSON_UINT)
+			return cur.DelArrayEntry(uint64(v.Int()), JSON_UINT)
 		}
 
 	case JSON_UINT:
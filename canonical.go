@@ -0,0 +1,153 @@
+package jsonez
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+/**
+ * Canonical JSON output as defined by RFC 8785, the
+ * JSON Canonicalization Scheme. Semantically identical
+ * trees always print to identical bytes, so the output
+ * can be hashed or signed
+ */
+
+/**
+ * Function to check that a string can be printed
+ * canonically
+ */
+func (p *printer) checkString(str string) {
+	if utf8.ValidString(str) == false {
+		p.fail(fmt.Errorf("jsonez: %w in string %q", ErrInvalidUTF8, str))
+	}
+}
+
+/**
+ * Function to print a number as an IEEE 754 double in
+ * the ECMAScript format. Integers beyond 2^53 are
+ * rounded like JavaScript does
+ */
+func (p *printer) printCanonicalNumber(cur *GoJSON) {
+	var f float64
+
+	switch cur.Jsontype {
+	case JSON_INT:
+		f = float64(cur.Valint)
+
+	case JSON_UINT:
+		f = float64(cur.Valuint)
+
+	case JSON_DOUBLE:
+		f = cur.Valdouble
+
+	case JSON_NUMBER:
+		var err error
+
+		if f, err = strconv.ParseFloat(cur.Valnum, 64); err != nil {
+			p.fail(fmt.Errorf("jsonez: %w: %s overflows a double", ErrNumberRange, cur.Valnum))
+		}
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		p.fail(fmt.Errorf("jsonez: %w: %v can't be represented in JSON", ErrNumberRange, f))
+		p.buf = append(p.buf, "null"...)
+		return
+	}
+
+	/*
+	 * Negative zero is printed as 0
+	 */
+	if f == 0 {
+		f = 0
+	}
+
+	p.buf = printDouble(p.buf, f, &PrintOptions{})
+}
+
+/**
+ * Function to get the key of a rune for sorting
+ * strings by their UTF-16 code units
+ */
+func utf16Key(r rune) uint32 {
+	if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+		return uint32(r1)<<16 | uint32(r2)
+	}
+
+	return uint32(r) << 16
+}
+
+/**
+ * Function to compare two strings by their UTF-16
+ * code units, as required for sorting keys
+ */
+func utf16Less(a, b string) bool {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+
+		if ra != rb {
+			return utf16Key(ra) < utf16Key(rb)
+		}
+
+		a, b = a[na:], b[nb:]
+	}
+
+	return len(a) < len(b)
+}
+
+/**
+ * Function to print an object with its keys sorted.
+ * Duplicate keys have no canonical form and fail
+ */
+func (p *printer) printCanonicalObject(cur *GoJSON, depth int) {
+	var members []*GoJSON
+
+	for child := cur.Child; child != nil; child = child.Next {
+		p.checkString(child.Key)
+		members = append(members, child)
+	}
+
+	sort.SliceStable(members, func(i, j int) bool {
+		return utf16Less(members[i].Key, members[j].Key)
+	})
+
+	p.buf = append(p.buf, '{')
+
+	for i, child := range members {
+		if i > 0 {
+			if child.Key == members[i-1].Key {
+				p.fail(fmt.Errorf("jsonez: %w %q", ErrDuplicateKey, child.Key))
+			}
+
+			p.buf = append(p.buf, ',')
+		}
+
+		p.buf = printString(p.buf, child.Key, p.opts)
+		p.buf = append(p.buf, ':')
+		p.printValue(child, depth, layoutCompact)
+	}
+
+	p.buf = append(p.buf, '}')
+}
+
+/**
+ * Function to print the GoJSON tree from root in the
+ * canonical form of RFC 8785: sorted keys, no whitespace,
+ * ECMAScript number formatting and minimal string
+ * escaping. Fails for NaN and infinite numbers, invalid
+ * UTF-8 and duplicate keys
+ */
+func GoJSONPrintCanonical(root *GoJSON) ([]byte, error) {
+	p := printer{opts: &PrintOptions{}, canonical: true}
+	p.printValue(root, 0, layoutCompact)
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return p.buf, nil
+}
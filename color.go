@@ -0,0 +1,137 @@
+package jsonez
+
+import (
+	"io"
+	"os"
+)
+
+/**
+ * ANSI colored output for terminals
+ */
+
+/*
+ * When to color the output
+ */
+const (
+	/** Never color the output */
+	COLOR_NEVER = iota
+
+	/**
+	 * Color the output of an Encoder writing to a
+	 * terminal, unless the NO_COLOR environment
+	 * variable is set. Output printed to a slice
+	 * isn't colored
+	 */
+	COLOR_AUTO
+
+	/** Always color the output */
+	COLOR_ALWAYS
+)
+
+/*
+ * ANSI escape sequences written before each kind of
+ * token. An empty sequence leaves the token uncolored
+ */
+type Colors struct {
+	Key    string
+	String string
+	Number string
+	Bool   string
+	Null   string
+}
+
+/*
+ * Colors used when PrintOptions.Colors isn't set
+ */
+var DefaultColors = Colors{
+	Key:    "\x1b[34;1m",
+	String: "\x1b[32m",
+	Number: "\x1b[36m",
+	Bool:   "\x1b[33m",
+	Null:   "\x1b[90m",
+}
+
+/**
+ * Escape sequence written after each colored token
+ */
+const colorReset = "\x1b[0m"
+
+/**
+ * Function to check if w is a terminal
+ */
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+/**
+ * Function to get the colors for printing to w, nil
+ * when the output isn't colored. w is nil when
+ * printing to a slice
+ */
+func colorsFor(w io.Writer, opts *PrintOptions) *Colors {
+	switch opts.ColorMode {
+	case COLOR_ALWAYS:
+
+	case COLOR_AUTO:
+		if w == nil || !isTerminal(w) || os.Getenv("NO_COLOR") != "" {
+			return nil
+		}
+
+	default:
+		return nil
+	}
+
+	if opts.Colors != nil {
+		return opts.Colors
+	}
+
+	return &DefaultColors
+}
+
+/**
+ * Function to get the color of a value, "" for
+ * arrays, objects and uncolored output
+ */
+func (p *printer) valueColor(cur *GoJSON) string {
+	if p.colors == nil {
+		return ""
+	}
+
+	switch cur.Jsontype {
+	case JSON_NULL:
+		return p.colors.Null
+	case JSON_BOOL:
+		return p.colors.Bool
+	case JSON_INT, JSON_UINT, JSON_DOUBLE, JSON_NUMBER:
+		return p.colors.Number
+	case JSON_STRING:
+		return p.colors.String
+	}
+
+	return ""
+}
+
+/**
+ * Function to start a colored token
+ */
+func (p *printer) startColor(code string) {
+	p.buf = append(p.buf, code...)
+	p.lineColor += len(code)
+}
+
+/**
+ * Function to end a colored token
+ */
+func (p *printer) endColor(code string) {
+	if code != "" {
+		p.buf = append(p.buf, colorReset...)
+		p.lineColor += len(colorReset)
+	}
+}
@@ -0,0 +1,398 @@
+package jsonez
+
+import (
+	"io"
+)
+
+/**
+ * Pull based access to a stream of JSON tokens
+ */
+
+/*
+ * Token holds a value of one of these types:
+ *
+ *	Delim, for the four JSON delimiters [ ] { }
+ *	bool, for JSON booleans
+ *	int64, uint64 or float64, for JSON numbers of
+ *	       type JSON_INT, JSON_UINT and JSON_DOUBLE
+ *	Number, for JSON numbers with NUMBER_LITERAL
+ *	string, for JSON strings and object keys
+ *	nil, for JSON null
+ */
+type Token interface{}
+
+/*
+ * Delim is one of the JSON array or object delimiters
+ */
+type Delim rune
+
+func (d Delim) String() string {
+	return string(d)
+}
+
+/*
+ * Number is the literal text of a JSON number
+ */
+type Number string
+
+/*
+ * Decoder states, describing what is expected next
+ */
+const (
+	/** A top level value */
+	decodeTop = iota
+
+	/** First value of an array or ']' */
+	decodeArrayStart
+
+	/** ',' or ']' after an array value */
+	decodeArrayValue
+
+	/** Array value after ',' */
+	decodeArrayComma
+
+	/** First key of an object or '}' */
+	decodeObjectStart
+
+	/** Value after an object key */
+	decodeObjectKey
+
+	/** ',' or '}' after an object value */
+	decodeObjectValue
+
+	/** Object key after ',' */
+	decodeObjectComma
+)
+
+/*
+ * Decoder reads a stream of JSON values token by token.
+ * Individual values can be materialized as GoJSON trees
+ * with Decode or skipped with Skip
+ */
+type Decoder struct {
+	p *parser
+
+	/** Current state, one of the decode* values */
+	state int
+
+	/**
+	 * States to return to and member counts of
+	 * the enclosing arrays and objects
+	 */
+	stack  []int
+	counts []int
+
+	/** First error, returned by all later calls */
+	err error
+}
+
+/*
+ * Function to create a Decoder reading from r. As with
+ * GoJSONParseReader, a *bufio.Reader is left positioned
+ * right after the last token read
+ */
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, ParseOptions{})
+}
+
+/*
+ * Function to create a Decoder reading from r applying
+ * the limits and policies in opts
+ */
+func NewDecoderWithOptions(r io.Reader, opts ParseOptions) *Decoder {
+	return &Decoder{p: newReaderParser(r, opts)}
+}
+
+/**
+ * Function to get the error for the current token when
+ * it isn't valid in the current state
+ */
+func (d *Decoder) unexpected() error {
+	switch d.state {
+	case decodeArrayStart:
+		return d.p.unexpected("value or ']'")
+	case decodeArrayValue:
+		return d.p.unexpected("',' or ']'")
+	case decodeObjectStart:
+		return d.p.unexpected("string or '}'")
+	case decodeObjectValue:
+		return d.p.unexpected("',' or '}'")
+	case decodeObjectComma:
+		return d.p.unexpected("string")
+	}
+
+	return d.p.unexpected("value")
+}
+
+/**
+ * Function to move to the next non whitespace byte,
+ * consuming the ',' between array values. Returns
+ * io.EOF at the end of the stream between top level
+ * values
+ */
+func (d *Decoder) next() error {
+	if d.err != nil {
+		return d.err
+	}
+
+	if d.p.nextToken() == false {
+		if d.state == decodeTop && d.p.rerr == io.EOF {
+			return io.EOF
+		}
+
+		return d.unexpected()
+	}
+
+	if d.p.data[d.p.pos] == ',' {
+		switch d.state {
+		case decodeArrayValue:
+			d.state = decodeArrayComma
+		case decodeObjectValue:
+			d.state = decodeObjectComma
+		default:
+			return d.unexpected()
+		}
+
+		d.p.pos++
+		return d.next()
+	}
+
+	return nil
+}
+
+/**
+ * Function to check that a value can be read in the
+ * current state and count it as a member
+ */
+func (d *Decoder) beforeValue() error {
+	switch d.state {
+	case decodeTop, decodeObjectKey:
+		return nil
+
+	case decodeArrayStart, decodeArrayComma:
+		d.counts[len(d.counts)-1]++
+		return d.p.checkMembers(d.counts[len(d.counts)-1])
+	}
+
+	return d.unexpected()
+}
+
+/**
+ * Function to update the state after a complete value
+ */
+func (d *Decoder) afterValue() {
+	switch d.state {
+	case decodeArrayStart, decodeArrayComma:
+		d.state = decodeArrayValue
+	case decodeObjectKey:
+		d.state = decodeObjectValue
+	}
+}
+
+/**
+ * Function to enter an array or object
+ */
+func (d *Decoder) push(state int) error {
+	if err := d.p.enter(); err != nil {
+		return err
+	}
+
+	d.stack = append(d.stack, d.state)
+	d.counts = append(d.counts, 0)
+	d.state = state
+	d.p.pos++
+
+	return nil
+}
+
+/**
+ * Function to leave an array or object
+ */
+func (d *Decoder) pop() {
+	d.p.pos++
+	d.p.depth--
+	d.state = d.stack[len(d.stack)-1]
+	d.stack = d.stack[:len(d.stack)-1]
+	d.counts = d.counts[:len(d.counts)-1]
+	d.afterValue()
+}
+
+/**
+ * Function to record the result of an operation
+ */
+func (d *Decoder) done(err error) error {
+	if err != nil && err != io.EOF {
+		d.err = err
+	}
+
+	d.p.release()
+	return err
+}
+
+/*
+ * Token returns the next JSON token in the stream. The
+ * ',' and ':' separators are consumed and not returned.
+ * io.EOF is returned at the end of the stream
+ */
+func (d *Decoder) Token() (Token, error) {
+	var val GoJSON
+
+	if err := d.next(); err != nil {
+		return nil, d.done(err)
+	}
+
+	switch c := d.p.data[d.p.pos]; c {
+	case '[', '{':
+		if err := d.beforeValue(); err != nil {
+			return nil, d.done(err)
+		}
+
+		state := decodeArrayStart
+		if c == '{' {
+			state = decodeObjectStart
+		}
+
+		if err := d.push(state); err != nil {
+			return nil, d.done(err)
+		}
+
+		return Delim(c), d.done(nil)
+
+	case ']':
+		if d.state != decodeArrayStart && d.state != decodeArrayValue {
+			return nil, d.done(d.unexpected())
+		}
+
+		d.pop()
+		return Delim(c), d.done(nil)
+
+	case '}':
+		if d.state != decodeObjectStart && d.state != decodeObjectValue {
+			return nil, d.done(d.unexpected())
+		}
+
+		d.pop()
+		return Delim(c), d.done(nil)
+	}
+
+	/*
+	 * Read an object key along with the ':' following it
+	 */
+	if d.state == decodeObjectStart || d.state == decodeObjectComma {
+		d.counts[len(d.counts)-1]++
+
+		if err := d.p.checkMembers(d.counts[len(d.counts)-1]); err != nil {
+			return nil, d.done(err)
+		}
+
+		if err := d.p.parseKey(&val); err != nil {
+			return nil, d.done(err)
+		}
+
+		d.state = decodeObjectKey
+		return val.Key, d.done(nil)
+	}
+
+	if err := d.beforeValue(); err != nil {
+		return nil, d.done(err)
+	}
+
+	if err := d.p.parseValue(&val); err != nil {
+		return nil, d.done(err)
+	}
+
+	d.afterValue()
+
+	switch val.Jsontype {
+	case JSON_STRING:
+		return val.Valstr, d.done(nil)
+	case JSON_BOOL:
+		return val.Valbool, d.done(nil)
+	case JSON_INT:
+		return val.Valint, d.done(nil)
+	case JSON_UINT:
+		return val.Valuint, d.done(nil)
+	case JSON_DOUBLE:
+		return val.Valdouble, d.done(nil)
+	case JSON_NUMBER:
+		return Number(val.Valnum), d.done(nil)
+	}
+
+	return nil, d.done(nil)
+}
+
+/*
+ * More reports whether there is another element in the
+ * current array or object, or another top level value
+ */
+func (d *Decoder) More() bool {
+	if err := d.next(); err != nil {
+		return false
+	}
+
+	c := d.p.data[d.p.pos]
+	return c != ']' && c != '}'
+}
+
+/**
+ * Function to prepare for reading a complete value
+ */
+func (d *Decoder) prepareValue() error {
+	if err := d.next(); err != nil {
+		return err
+	}
+
+	return d.beforeValue()
+}
+
+/*
+ * Decode reads the next complete value into g, replacing
+ * its contents. Within an object, the key must be read
+ * with Token first
+ */
+func (d *Decoder) Decode(g *GoJSON) error {
+	if err := d.prepareValue(); err != nil {
+		return d.done(err)
+	}
+
+	val := new(GoJSON)
+
+	if err := d.p.parseValue(val); err != nil {
+		return d.done(err)
+	}
+
+	/*
+	 * Keep the links of g so it can be a
+	 * node of an existing tree
+	 */
+	val.Key, val.Next, val.Prev = g.Key, g.Next, g.Prev
+	*g = *val
+
+	d.afterValue()
+	return d.done(nil)
+}
+
+/*
+ * Skip reads past the next complete value after
+ * validating it, without building a GoJSON tree
+ */
+func (d *Decoder) Skip() error {
+	if err := d.prepareValue(); err != nil {
+		return d.done(err)
+	}
+
+	if err := d.p.skipValue(); err != nil {
+		return d.done(err)
+	}
+
+	d.afterValue()
+	return d.done(nil)
+}
+
+/*
+ * InputOffset returns the offset in the stream of the
+ * byte following the last token read
+ */
+func (d *Decoder) InputOffset() int64 {
+	return d.p.base + int64(d.p.pos)
+}
@@ -0,0 +1,70 @@
+package jsonez
+
+import (
+	"bufio"
+	"io"
+)
+
+/**
+ * Printing GoJSON trees to a stream
+ */
+
+/*
+ * Encoder writes GoJSON trees to a stream. The output is
+ * written out in chunks while the tree is walked, so the
+ * memory used doesn't depend on the size of the tree
+ */
+type Encoder struct {
+	w    *bufio.Writer
+	out  io.Writer
+	opts PrintOptions
+
+	/** Colors of the tokens, nil for uncolored output */
+	colors *Colors
+
+	/** Scratch buffer reused across calls to Encode */
+	buf []byte
+}
+
+/*
+ * Function to create an Encoder writing to w with
+ * the same output as GoJSONPrint
+ */
+func NewEncoder(w io.Writer) *Encoder {
+	return NewEncoderWithOptions(w, PrintOptions{})
+}
+
+/*
+ * Function to create an Encoder writing to w with
+ * the given printer options
+ */
+func NewEncoderWithOptions(w io.Writer, opts PrintOptions) *Encoder {
+	return &Encoder{
+		w:      bufio.NewWriterSize(w, printChunkSize),
+		out:    w,
+		opts:   opts,
+		colors: colorsFor(w, &opts),
+	}
+}
+
+/*
+ * Encode writes g to the stream and flushes it. Set
+ * TrailingNewline in the options to separate values
+ * when encoding several of them. On error the part of
+ * the value still buffered is dropped, so it doesn't
+ * reach the stream in front of the next value
+ */
+func (e *Encoder) Encode(g *GoJSON) error {
+	p := printer{buf: e.buf[:0], opts: &e.opts, w: e.w, colors: e.colors}
+
+	p.printRoot(g)
+	p.flush()
+	e.buf = p.buf
+
+	if p.err != nil {
+		e.w.Reset(e.out)
+		return p.err
+	}
+
+	return e.w.Flush()
+}
@@ -0,0 +1,242 @@
+package jsonez
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/**
+ * Error types returned by the package
+ */
+
+/*
+ * SyntaxError describes malformed JSON input along
+ * with the location where it was detected
+ */
+type SyntaxError struct {
+	/** Description of the error */
+	Msg string
+
+	/** Byte offset of the error in the input */
+	Offset int64
+
+	/** Line and byte column of the error, both starting at 1 */
+	Line, Column int
+
+	/** Token that was expected at the error location, if known */
+	Expected string
+
+	/** Snippet of the input surrounding the error location */
+	Context string
+
+	/**
+	 * Underlying cause such as ErrLimitExceeded or
+	 * ErrDuplicateKey, nil for grammar errors
+	 */
+	Err error
+}
+
+/**
+ * Maximum number of bytes shown on each side of
+ * the error location in SyntaxError.Context
+ */
+const syntaxContextLen = 16
+
+func (e *SyntaxError) Error() string {
+	errorStr := fmt.Sprintf("jsonez: %s at line %d, column %d (offset %d)",
+		e.Msg, e.Line, e.Column, e.Offset)
+
+	if e.Expected != "" {
+		errorStr += ", expected " + e.Expected
+	}
+
+	if e.Context != "" {
+		errorStr += " near " + strconv.Quote(e.Context)
+	}
+
+	return errorStr
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+/**
+ * Function to create a SyntaxError for the given offset in input
+ */
+func newSyntaxError(input []byte, offset int, msg, expected string) *SyntaxError {
+	if offset > len(input) {
+		offset = len(input)
+	}
+
+	lineStart := bytes.LastIndexByte(input[:offset], '\n') + 1
+
+	/*
+	 * Cut the context on UTF-8 boundaries so it
+	 * can be displayed as is
+	 */
+	start := offset - syntaxContextLen
+	if start < lineStart {
+		start = lineStart
+	}
+	for start < offset && !utf8.RuneStart(input[start]) {
+		start++
+	}
+
+	end := offset + syntaxContextLen
+	if end > len(input) {
+		end = len(input)
+	}
+	if i := bytes.IndexByte(input[offset:end], '\n'); i >= 0 {
+		end = offset + i
+	}
+	for end < len(input) && end > offset && !utf8.RuneStart(input[end]) {
+		end--
+	}
+
+	return &SyntaxError{
+		Msg:      msg,
+		Offset:   int64(offset),
+		Line:     bytes.Count(input[:offset], []byte{'\n'}) + 1,
+		Column:   offset - lineStart + 1,
+		Expected: expected,
+		Context:  string(input[start:end]),
+	}
+}
+
+/**
+ * Function to describe a byte of input in error messages
+ */
+func quoteChar(c byte) string {
+	if c == '\'' {
+		return `'\''`
+	}
+
+	if c == '"' {
+		return `'"'`
+	}
+
+	s := strconv.Quote(string([]byte{c}))
+	return "'" + s[1:len(s)-1] + "'"
+}
+
+/*
+ * Errors returned by the builder and query functions.
+ * The returned errors wrap these and can be checked
+ * using errors.Is
+ */
+var (
+	ErrPathNotFound    = errors.New("path not found")
+	ErrTypeMismatch    = errors.New("type mismatch")
+	ErrIndexOutOfRange = errors.New("index out of range")
+	ErrValueNotFound   = errors.New("value not found")
+	ErrUnsupportedType = errors.New("unsupported value type")
+	ErrInvalidPointer  = errors.New("invalid JSON pointer")
+)
+
+/*
+ * Errors wrapped by a SyntaxError when the input
+ * violates one of the ParseOptions
+ */
+var (
+	ErrLimitExceeded = errors.New("parse limit exceeded")
+	ErrDuplicateKey  = errors.New("duplicate key")
+	ErrNumberRange   = errors.New("number out of range")
+)
+
+/*
+ * Error returned by the canonical printer for strings
+ * that aren't valid UTF-8
+ */
+var ErrInvalidUTF8 = errors.New("invalid UTF-8")
+
+/*
+ * PathError records the path of keys that
+ * failed along with the underlying error
+ */
+type PathError struct {
+	/** Keys leading up to and including the failing key */
+	Path []string
+
+	Err error
+}
+
+func (e *PathError) Error() string {
+	if len(e.Path) == 0 {
+		return "jsonez: " + e.Err.Error()
+	}
+
+	return "jsonez: " + strings.Join(e.Path, "/") + ": " + e.Err.Error()
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+/**
+ * Function to create a PathError for the first n keys of path
+ */
+func pathError(path []string, n int, err error) error {
+	return &PathError{Path: append([]string(nil), path[:n]...), Err: err}
+}
+
+/*
+ * TypeError is returned when a GoJSON object is not of
+ * the type required by the operation. It matches
+ * ErrTypeMismatch with errors.Is
+ */
+type TypeError struct {
+	/** Path of the GoJSON object */
+	Path []string
+
+	/** Expected and actual JSON types */
+	Expected, Actual int
+}
+
+func (e *TypeError) Error() string {
+	errorStr := "jsonez: "
+
+	if len(e.Path) != 0 {
+		errorStr += strings.Join(e.Path, "/") + ": "
+	}
+
+	return errorStr + fmt.Sprintf("expected %s, found %s",
+		typeName(e.Expected), typeName(e.Actual))
+}
+
+func (e *TypeError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+/**
+ * Function to create a TypeError for path
+ */
+func typeError(path []string, expected, actual int) error {
+	return &TypeError{
+		Path:     append([]string(nil), path...),
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
+/*
+ * IndexError is returned when an array index is
+ * out of range. It matches ErrIndexOutOfRange with
+ * errors.Is
+ */
+type IndexError struct {
+	Index, Size int
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("jsonez: index %d out of range for array of size %d",
+		e.Index, e.Size)
+}
+
+func (e *IndexError) Is(target error) bool {
+	return target == ErrIndexOutOfRange
+}
@@ -1,9 +1,16 @@
 package jsonez
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestSimpleParse(t *testing.T) {
@@ -712,3 +719,2024 @@ func TestComplexParse(t *testing.T) {
 
 	return
 }
+
+func TestStringEscapes(t *testing.T) {
+	input := []byte(`{
+		"quote": "say \"hi\"",
+		"ctrl": "a\nb\tc\\d\/e\b\f\r",
+		"uni": "caf\u00e9 \u4e16\u754c",
+		"pair": "\ud83d\ude00",
+		"lone": "\ud800x",
+		"raw": "naïve",
+		" spaced ": "  padded  ",
+		"k\u0065y": 1
+	}`)
+
+	g, err := GoJSONParse(input)
+	if err != nil {
+		t.Errorf("%s: GoJSONParse failed with error %s", funcName(), err)
+		return
+	}
+
+	expected := map[string]string{
+		"quote":    `say "hi"`,
+		"ctrl":     "a\nb\tc\\d/e\b\f\r",
+		"uni":      "café 世界",
+		"pair":     "\U0001F600",
+		"lone":     "�x",
+		"raw":      "naïve",
+		" spaced ": "  padded  ",
+	}
+
+	for key, val := range expected {
+		s, err := g.GetStringVal(key)
+		if err != nil {
+			t.Errorf("%s: GetStringVal for key %q failed with error %s", funcName(), key, err)
+		} else if s != val {
+			t.Errorf("%s: key %q returned %q while expected was %q", funcName(), key, s, val)
+		}
+	}
+
+	if _, err = g.Get("key"); err != nil {
+		t.Errorf("%s: escaped key was not decoded", funcName())
+	}
+
+	invalid := []string{
+		`"abc`,
+		`"a\qb"`,
+		`"\u12"`,
+		`"\u12G4"`,
+		"\"a\tb\"",
+		"\"a\x01b\"",
+		"\"\xff\"",
+		`"abc\`,
+	}
+
+	for _, in := range invalid {
+		if _, err = GoJSONParse([]byte(in)); err == nil {
+			t.Errorf("%s: GoJSONParse of %q didn't fail as expected", funcName(), in)
+		}
+	}
+}
+
+func TestPrintEscapes(t *testing.T) {
+	input := []byte(`{"a\"b": "line1\nline2\t\"quoted\" \\ \u0001 <b>&amp;</b> café 😀"}`)
+
+	g, err := GoJSONParse(input)
+	if err != nil {
+		t.Errorf("%s: GoJSONParse failed with error %s", funcName(), err)
+		return
+	}
+
+	outputs := map[string][]byte{
+		"default": GoJSONPrint(g),
+		"html":    GoJSONPrintWithOptions(g, PrintOptions{EscapeHTML: true}),
+		"ascii":   GoJSONPrintWithOptions(g, PrintOptions{EscapeNonASCII: true}),
+	}
+
+	for name, output := range outputs {
+		g2, err := GoJSONParse(output)
+		if err != nil {
+			t.Errorf("%s: %s output %s didn't parse with error %s", funcName(), name, output, err)
+			continue
+		}
+
+		if string(GoJSONPrint(g2)) != string(outputs["default"]) {
+			t.Errorf("%s: %s output %s didn't round trip", funcName(), name, output)
+		}
+	}
+
+	if strings.ContainsAny(string(outputs["html"]), "<>&") {
+		t.Errorf("%s: html output %s contains unescaped characters", funcName(), outputs["html"])
+	}
+
+	if !strings.Contains(string(outputs["ascii"]), `caf\u00e9 \ud83d\ude00`) {
+		t.Errorf("%s: ascii output %s is not escaped as expected", funcName(), outputs["ascii"])
+	}
+
+	for _, c := range outputs["ascii"] {
+		if c >= 0x80 {
+			t.Errorf("%s: ascii output %s contains non-ASCII bytes", funcName(), outputs["ascii"])
+			break
+		}
+	}
+
+	output := string(GoJSONPrint(AllocString("bad \xff utf8")))
+	if output != "\"bad � utf8\"" {
+		t.Errorf("%s: invalid UTF-8 printed as %s", funcName(), output)
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	input := []byte("{\n\t\"val1\": \"foo\",\n\t\"val2\": ?\n}")
+
+	_, err := GoJSONParse(input)
+	if err == nil {
+		t.Errorf("%s: GoJSONParse didn't fail as expected", funcName())
+		return
+	}
+
+	var serr *SyntaxError
+	if !errors.As(err, &serr) {
+		t.Errorf("%s: error %s is not a SyntaxError", funcName(), err)
+		return
+	}
+
+	if serr.Offset != 27 || serr.Line != 3 || serr.Column != 10 {
+		t.Errorf("%s: error reported at offset %d line %d column %d while expected "+
+			"was offset 27 line 3 column 10", funcName(), serr.Offset, serr.Line, serr.Column)
+	}
+
+	if serr.Expected != "value" || serr.Context != "\t\"val2\": ?" {
+		t.Errorf("%s: error has expected %q and context %q", funcName(), serr.Expected, serr.Context)
+	}
+
+	t.Logf("%s: GoJSONParse failed as expected with error %s", funcName(), err)
+}
+
+func TestBuilderErrors(t *testing.T) {
+	g, err := GoJSONParse([]byte(`{"outer": {"val1": "foo", "val5": [1, 2, 3]}}`))
+	if err != nil {
+		t.Errorf("%s: GoJSONParse failed with error %s", funcName(), err)
+		return
+	}
+
+	var perr *PathError
+	_, err = g.Get("outer", "missing", "deeper")
+	if !errors.Is(err, ErrPathNotFound) || !errors.As(err, &perr) {
+		t.Errorf("%s: Get returned %v while ErrPathNotFound was expected", funcName(), err)
+	} else if strings.Join(perr.Path, "/") != "outer/missing" {
+		t.Errorf("%s: PathError has path %v", funcName(), perr.Path)
+	}
+
+	var terr *TypeError
+	_, err = g.GetIntVal("outer", "val1")
+	if !errors.Is(err, ErrTypeMismatch) || !errors.As(err, &terr) {
+		t.Errorf("%s: GetIntVal returned %v while ErrTypeMismatch was expected", funcName(), err)
+	} else if terr.Expected != JSON_INT || terr.Actual != JSON_STRING {
+		t.Errorf("%s: TypeError has expected %d and actual %d", funcName(), terr.Expected, terr.Actual)
+	}
+
+	if err = g.AddToArray(1, "outer", "val1"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("%s: AddToArray returned %v while ErrTypeMismatch was expected", funcName(), err)
+	}
+
+	/*
+	 * AddVal doesn't attach members to non objects
+	 */
+	err = g.AddVal(1, "outer", "val1", "new")
+	if !errors.As(err, &terr) || strings.Join(terr.Path, "/") != "outer/val1" || terr.Actual != JSON_STRING {
+		t.Errorf("%s: AddVal under a string returned %v while ErrTypeMismatch was expected", funcName(), err)
+	}
+
+	if err = g.AddVal(1, "outer", "val5", "new"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("%s: AddVal under an array returned %v while ErrTypeMismatch was expected", funcName(), err)
+	}
+
+	if err = g.AddVal(1, "outer", "val1"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("%s: AddVal on a string returned %v while ErrTypeMismatch was expected", funcName(), err)
+	}
+
+	if s, _ := g.GetStringVal("outer", "val1"); s != "foo" || g.GetObjectEntry("outer").GetObjectEntry("val1").Child != nil {
+		t.Errorf("%s: AddVal changed the string to %q", funcName(), s)
+	}
+
+	if err = g.DelVal("outer", "missing"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("%s: DelVal returned %v while ErrPathNotFound was expected", funcName(), err)
+	}
+
+	arr, _ := g.Get("outer", "val5")
+	if _, err = arr.GetArrayElemByIndex(3); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("%s: GetArrayElemByIndex returned %v while ErrIndexOutOfRange was expected", funcName(), err)
+	}
+
+	if err = arr.DelIndexFromArray(-1); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("%s: DelIndexFromArray returned %v while ErrIndexOutOfRange was expected", funcName(), err)
+	}
+
+	if _, err = arr.GetArrayEntry("foo", JSON_STRING); !errors.Is(err, ErrValueNotFound) {
+		t.Errorf("%s: GetArrayEntry returned %v while ErrValueNotFound was expected", funcName(), err)
+	}
+
+	/*
+	 * Values of any integer width are matched, and only
+	 * against elements of the same type
+	 */
+	mixed, _ := GoJSONParse([]byte(`{"a": ["", 0, 2, 4, -7]}`))
+
+	if err = mixed.DelFromArray(int64(4), "a"); err != nil {
+		t.Errorf("%s: DelFromArray of int64 failed with error %s", funcName(), err)
+	}
+
+	if err = mixed.DelFromArray(uint64(2), "a"); err != nil {
+		t.Errorf("%s: DelFromArray of uint64 failed with error %s", funcName(), err)
+	}
+
+	if err = mixed.DelFromArray(int8(-7), "a"); err != nil {
+		t.Errorf("%s: DelFromArray of int8 failed with error %s", funcName(), err)
+	}
+
+	arr, _ = mixed.Get("a")
+	if entry, err := arr.GetArrayEntry(uint(0), JSON_UINT); err != nil || entry.Jsontype != JSON_UINT {
+		t.Errorf("%s: GetArrayEntry of uint 0 returned %v with error %v", funcName(), entry, err)
+	}
+
+	if _, err = arr.GetArrayEntry(1.5, JSON_INT); !errors.Is(err, ErrValueNotFound) {
+		t.Errorf("%s: GetArrayEntry of a double for JSON_INT returned %v", funcName(), err)
+	}
+
+	if output := string(GoJSONPrintCompact(mixed)); output != `{"a":["",0]}` {
+		t.Errorf("%s: DelFromArray left %s", funcName(), output)
+	}
+
+	if err = g.AddVal([]int{1}, "outer", "val6"); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("%s: AddVal returned %v while ErrUnsupportedType was expected", funcName(), err)
+	}
+}
+
+func TestStrictParse(t *testing.T) {
+	/*
+	 * Cases taken from the JSONTestSuite corpus
+	 */
+	accept := []string{
+		`[[]   ]`,
+		`[""]`,
+		`[]`,
+		`["a"]`,
+		`[false]`,
+		`[null, 1, "1", {}]`,
+		`[null]`,
+		" [1\n]",
+		`[1,null,null,null,2]`,
+		`[2] `,
+		`[123e65]`,
+		`[0e+1]`,
+		`[0e1]`,
+		`[ 4]`,
+		`[-0.000000000000000000000000000000000000000000000000000000000000000000000000000001]`,
+		`[20e1]`,
+		`[-0]`,
+		`[-123]`,
+		`[-1]`,
+		`[1E22]`,
+		`[1E-2]`,
+		`[1E+2]`,
+		`[123e45]`,
+		`[123.456e78]`,
+		`[1e-2]`,
+		`[1e+2]`,
+		`[123]`,
+		`[123.456789]`,
+		`{"asd":"sdf", "dfg":"fgh"}`,
+		`{"asd":"sdf"}`,
+		`{"a":"b","a":"c"}`,
+		`{"a":"b","a":"b"}`,
+		`{}`,
+		`{"":0}`,
+		`{"foo\u0000bar": 42}`,
+		`{ "min": -1.0e+28, "max": 1.0e+28 }`,
+		`{"a":[]}`,
+		`["\u0060\u012a\u12ab"]`,
+		`["\uD801\udc37"]`,
+		`["\"\\\/\b\f\n\r\t"]`,
+		`["\\u0000"]`,
+		`["\""]`,
+		`["a/*b*/c/*d//e"]`,
+		`["\\a"]`,
+		`[" "]`,
+		`["\u0000"]`,
+		"[\"\xef\xbf\xbf\"]",
+		"[\"\xe2\x82\xac\xf0\x9d\x84\x9e\"]",
+		`["\uFFFF"]`,
+		"[\"\x7f\"]",
+		`"asd"`,
+		`false`,
+		`42`,
+		`-0.1`,
+		`null`,
+		`true`,
+		` [] `,
+		`{"a":{"b":{"c":[[[{}]]]}}}`,
+		"[1]\n",
+	}
+
+	reject := []string{
+		``,
+		` `,
+		`[1 true]`,
+		`["": 1]`,
+		`[""],`,
+		`[,1]`,
+		`[1,,2]`,
+		`["x",,]`,
+		`["x"]]`,
+		`["",]`,
+		`["x"`,
+		`[x`,
+		`[3[4]]`,
+		`[1:2]`,
+		`[,]`,
+		`[-]`,
+		`[   , ""]`,
+		`["a",` + "\n" + `4` + "\n" + `,1,`,
+		`[1,]`,
+		`[1,,]`,
+		"[\"\x0b\"a\"]",
+		`[*]`,
+		`[""`,
+		`[1,`,
+		`[1,1,]`,
+		"[\"\x00\"]",
+		"[\f]",
+		`[++1234]`,
+		`[+1]`,
+		`[+Inf]`,
+		`[-01]`,
+		`[-1.0.]`,
+		`[-2.]`,
+		`[-NaN]`,
+		`[.-1]`,
+		`[.2e-3]`,
+		`[0.1.2]`,
+		`[0.3e+]`,
+		`[0.3e]`,
+		`[0.e1]`,
+		`[0E+]`,
+		`[0E]`,
+		`[0e+]`,
+		`[0e]`,
+		`[1.0e+]`,
+		`[1.0e-]`,
+		`[1.0e]`,
+		`[1 000.0]`,
+		`[1eE2]`,
+		`[2.e+3]`,
+		`[2.e-3]`,
+		`[2.e3]`,
+		`[9.e+]`,
+		`[Inf]`,
+		`[NaN]`,
+		`[012]`,
+		`[0x1]`,
+		`[0x42]`,
+		`[1.]`,
+		`[.123]`,
+		`[1e]`,
+		`[Infinity]`,
+		`[-Infinity]`,
+		`[-foo]`,
+		`[- 1]`,
+		`[1+2]`,
+		`[1ea]`,
+		`[1.2a-3]`,
+		`["x", truth]`,
+		`[True]`,
+		`[nul]`,
+		`[nulll]`,
+		`[tru]`,
+		`[fals]`,
+		`{"x", null}`,
+		`{"x"::"b"}`,
+		`{[: "x"}`,
+		`{"a" b}`,
+		`{key: 'value'}`,
+		`{"a":"a" 123}`,
+		`{1:1}`,
+		`{null:null,null:null}`,
+		`{"id":0,,,,,}`,
+		`{'a':0}`,
+		`{"id":0,}`,
+		`{"a":"b"}/**/`,
+		`{"a":"b"}//`,
+		`{"a":"b"}#`,
+		`{"a":"a`,
+		`{"a" "b"}`,
+		`{"a"`,
+		`{"a":`,
+		`{"a":"b",}`,
+		`{"a":"b",,"c":"d"}`,
+		`{a: "b"}`,
+		`{"a":"a`,
+		`{`,
+		`{"a" 1}`,
+		`{"a":1,`,
+		`["\x00"]`,
+		`["\\\"]`,
+		`["\uD800\u"]`,
+		`["\uD800\u1"]`,
+		`["\a"]`,
+		`["\uqqqq"]`,
+		"[\"\t\"]",
+		"[\"new\nline\"]",
+		`['single quote']`,
+		`abc`,
+		"\xef\xbb\xbf{}",
+		`1 2`,
+		`[] []`,
+		`[]x`,
+		`{}}`,
+		" []",
+		`[1]` + "\x00",
+		`"`,
+		`]`,
+		`}`,
+		`:`,
+		`,`,
+		`[`,
+		`["a"`,
+		`[{}`,
+		`{"a":[}`,
+		"[\"\xff\"]",
+		"[\"\xed\xa0\x80\"]",
+		"[\"\xc0\xaf\"]",
+	}
+
+	for _, in := range accept {
+		if _, err := GoJSONParse([]byte(in)); err != nil {
+			t.Errorf("%s: GoJSONParse of %q failed with error %s", funcName(), in, err)
+		}
+	}
+
+	for _, in := range reject {
+		if _, err := GoJSONParse([]byte(in)); err == nil {
+			t.Errorf("%s: GoJSONParse of %q didn't fail as expected", funcName(), in)
+		}
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	deep := strings.Repeat("[", 20) + strings.Repeat("]", 20)
+
+	limits := []struct {
+		input string
+		opts  ParseOptions
+	}{
+		{deep, ParseOptions{MaxDepth: 10}},
+		{strings.Repeat("[", 100000), ParseOptions{}},
+		{`{"val1": "foo"}`, ParseOptions{MaxInputBytes: 10}},
+		{`{"val1": "foo"}`, ParseOptions{MaxStringLen: 3}},
+		{`[1, 2, 3, 4]`, ParseOptions{MaxMembers: 3}},
+		{`{"a": 1, "b": 2}`, ParseOptions{MaxMembers: 1}},
+	}
+
+	for _, l := range limits {
+		_, err := GoJSONParseWithOptions([]byte(l.input), l.opts)
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("%s: parsing %.20q with %+v returned %v while ErrLimitExceeded "+
+				"was expected", funcName(), l.input, l.opts, err)
+		}
+	}
+
+	if _, err := GoJSONParseWithOptions([]byte(deep), ParseOptions{MaxDepth: 20}); err != nil {
+		t.Errorf("%s: parsing with MaxDepth 20 failed with error %s", funcName(), err)
+	}
+
+	input := []byte(`{"a": 1, "b": 2, "a": 3}`)
+
+	_, err := GoJSONParseWithOptions(input, ParseOptions{DuplicateKeys: DUPKEY_REJECT})
+	var serr *SyntaxError
+	if !errors.Is(err, ErrDuplicateKey) || !errors.As(err, &serr) || serr.Offset != 17 {
+		t.Errorf("%s: DUPKEY_REJECT returned %v", funcName(), err)
+	}
+
+	expected := map[int]string{
+		DUPKEY_ALLOW: `{"a":1,"b":2,"a":3}`,
+		DUPKEY_FIRST: `{"a":1,"b":2}`,
+		DUPKEY_LAST:  `{"a":3,"b":2}`,
+	}
+
+	for policy, out := range expected {
+		g, err := GoJSONParseWithOptions(input, ParseOptions{DuplicateKeys: policy})
+		if err != nil {
+			t.Errorf("%s: policy %d failed with error %s", funcName(), policy, err)
+			continue
+		}
+
+		output := strings.Join(strings.Fields(string(GoJSONPrint(g))), "")
+		if output != out {
+			t.Errorf("%s: policy %d produced %s while expected was %s", funcName(), policy, output, out)
+		}
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	long := strings.Repeat("0123456789\\n", 1000)
+	input := `{"outer": {"val1": "` + long + `", "val2": [1, 2.5, -3, true, null]}} 42 rest`
+
+	rd := bufio.NewReader(iotest.OneByteReader(strings.NewReader(input)))
+
+	g, err := GoJSONParseReader(rd)
+	if err != nil {
+		t.Errorf("%s: GoJSONParseReader failed with error %s", funcName(), err)
+		return
+	}
+
+	expected, _ := GoJSONParse([]byte(input[:strings.Index(input, " 42")]))
+	if string(GoJSONPrint(g)) != string(GoJSONPrint(expected)) {
+		t.Errorf("%s: GoJSONParseReader output differs from GoJSONParse", funcName())
+	}
+
+	/*
+	 * Reading must stop right after each value
+	 */
+	g, err = GoJSONParseReader(rd)
+	if err != nil || g.Valuint != 42 {
+		t.Errorf("%s: second value was not parsed, error %v", funcName(), err)
+	}
+
+	rest, _ := io.ReadAll(rd)
+	if string(rest) != " rest" {
+		t.Errorf("%s: remaining input is %q while expected was \" rest\"", funcName(), rest)
+	}
+
+	_, err = GoJSONParseReader(strings.NewReader("  \n "))
+	if err != io.EOF {
+		t.Errorf("%s: empty input returned %v while io.EOF was expected", funcName(), err)
+	}
+
+	/*
+	 * Errors must be located in the whole stream
+	 */
+	bad := strings.Repeat("[\n", 3000) + "x"
+	_, err = GoJSONParseReader(iotest.HalfReader(strings.NewReader(bad)))
+	var serr *SyntaxError
+	if !errors.As(err, &serr) {
+		t.Errorf("%s: GoJSONParseReader returned %v while a SyntaxError was expected", funcName(), err)
+	} else if serr.Offset != 6000 || serr.Line != 3001 || serr.Column != 1 {
+		t.Errorf("%s: error reported at offset %d line %d column %d", funcName(),
+			serr.Offset, serr.Line, serr.Column)
+	}
+
+	_, err = GoJSONParseReader(iotest.TimeoutReader(strings.NewReader(strings.Repeat(" ", 5000) + "[1")))
+	if err != iotest.ErrTimeout {
+		t.Errorf("%s: read error was reported as %v", funcName(), err)
+	}
+
+	_, err = GoJSONParseReaderWithOptions(strings.NewReader(input), ParseOptions{MaxInputBytes: 100})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("%s: MaxInputBytes returned %v while ErrLimitExceeded was expected", funcName(), err)
+	}
+}
+
+/*
+ * Handler recording the events as text and
+ * skipping the entries with key "skip"
+ */
+type recordHandler struct {
+	events []string
+}
+
+func (h *recordHandler) StartObject() error { h.events = append(h.events, "{"); return nil }
+func (h *recordHandler) EndObject() error   { h.events = append(h.events, "}"); return nil }
+func (h *recordHandler) EndArray() error    { h.events = append(h.events, "]"); return nil }
+func (h *recordHandler) Null() error        { h.events = append(h.events, "null"); return nil }
+
+func (h *recordHandler) StartArray() error {
+	if len(h.events) > 0 && h.events[len(h.events)-1] == "key:skiparr" {
+		return SkipValue
+	}
+
+	h.events = append(h.events, "[")
+	return nil
+}
+
+func (h *recordHandler) Key(key string) error {
+	h.events = append(h.events, "key:"+key)
+
+	if key == "skip" {
+		return SkipValue
+	}
+	return nil
+}
+
+func (h *recordHandler) String(val string) error {
+	h.events = append(h.events, "str:"+val)
+	return nil
+}
+
+func (h *recordHandler) Number(val *GoJSON) error {
+	h.events = append(h.events, "num:"+strings.TrimSpace(string(GoJSONPrint(val))))
+	return nil
+}
+
+func (h *recordHandler) Bool(val bool) error {
+	h.events = append(h.events, fmt.Sprint(val))
+	return nil
+}
+
+func TestWalk(t *testing.T) {
+	input := `{"val1": "foo", "skip": {"a": [1, 2, {"b": null}]}, "val3": 1234,
+		"skiparr": [1, [2, 3]], "val5": [-1, true, null, "x"]}`
+	expected := "{ key:val1 str:foo key:skip key:val3 num:1234 key:skiparr " +
+		"key:val5 [ num:-1 true null str:x ] }"
+
+	h := &recordHandler{}
+	if err := GoJSONWalk([]byte(input), h); err != nil {
+		t.Errorf("%s: GoJSONWalk failed with error %s", funcName(), err)
+	} else if strings.Join(h.events, " ") != expected {
+		t.Errorf("%s: GoJSONWalk reported %v", funcName(), h.events)
+	}
+
+	h = &recordHandler{}
+	if err := GoJSONWalkReader(iotest.OneByteReader(strings.NewReader(input)), h); err != nil {
+		t.Errorf("%s: GoJSONWalkReader failed with error %s", funcName(), err)
+	} else if strings.Join(h.events, " ") != expected {
+		t.Errorf("%s: GoJSONWalkReader reported %v", funcName(), h.events)
+	}
+
+	/*
+	 * Skipped values must still be valid JSON
+	 */
+	err := GoJSONWalk([]byte(`{"skip": [1, 2,]}`), &recordHandler{})
+	var serr *SyntaxError
+	if !errors.As(err, &serr) {
+		t.Errorf("%s: GoJSONWalk returned %v while a SyntaxError was expected", funcName(), err)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	input := `{"items": [{"id": 1, "tags": ["a"]}, {"id": -2, "skip": [1, 2]}, {"id": 3.5}], "count": 3, "ok": true, "none": null}`
+
+	d := NewDecoder(strings.NewReader(input))
+
+	var tokens []string
+	var ids []string
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Errorf("%s: Token failed with error %s", funcName(), err)
+			return
+		}
+
+		tokens = append(tokens, fmt.Sprintf("%T:%v", tok, tok))
+
+		if tok != "items" {
+			continue
+		}
+
+		/*
+		 * Walk the array and decode each element
+		 */
+		if tok, err = d.Token(); tok != Delim('[') {
+			t.Errorf("%s: expected '[' but got %v, error %v", funcName(), tok, err)
+			return
+		}
+
+		for d.More() {
+			var g GoJSON
+
+			if err = d.Decode(&g); err != nil {
+				t.Errorf("%s: Decode failed with error %s", funcName(), err)
+				return
+			}
+
+			id, _ := g.Get("id")
+			ids = append(ids, strings.TrimSpace(string(GoJSONPrint(id))))
+		}
+
+		if tok, err = d.Token(); tok != Delim(']') {
+			t.Errorf("%s: expected ']' but got %v, error %v", funcName(), tok, err)
+			return
+		}
+	}
+
+	expected := "jsonez.Delim:{ string:items string:count uint64:3 string:ok bool:true " +
+		"string:none <nil>:<nil> jsonez.Delim:}"
+
+	if strings.Join(tokens, " ") != expected {
+		t.Errorf("%s: Token returned %v", funcName(), tokens)
+	}
+
+	if strings.Join(ids, " ") != "1 -2 3.5" {
+		t.Errorf("%s: Decode returned ids %v", funcName(), ids)
+	}
+
+	/*
+	 * Skip values and stop right after the second one
+	 */
+	rd := bufio.NewReader(strings.NewReader(`[1, 2] {"a": [3]} "x" rest`))
+	d = NewDecoder(rd)
+
+	if err := d.Skip(); err != nil {
+		t.Errorf("%s: Skip failed with error %s", funcName(), err)
+	}
+
+	if err := d.Skip(); err != nil {
+		t.Errorf("%s: Skip failed with error %s", funcName(), err)
+	}
+
+	if d.InputOffset() != 17 {
+		t.Errorf("%s: InputOffset returned %d while expected was 17", funcName(), d.InputOffset())
+	}
+
+	if rest, _ := io.ReadAll(rd); string(rest) != ` "x" rest` {
+		t.Errorf("%s: remaining input is %q", funcName(), rest)
+	}
+
+	invalid := []string{`[1 2]`, `{"a" 1}`, `{"a": 1,}`, `[1,]`, `]`, `{"a": 1]`, `[`}
+
+	for _, in := range invalid {
+		d = NewDecoder(strings.NewReader(in))
+
+		var err error
+		for err == nil {
+			_, err = d.Token()
+		}
+
+		if _, ok := err.(*SyntaxError); !ok {
+			t.Errorf("%s: tokenizing %q returned %v while a SyntaxError was expected", funcName(), in, err)
+		}
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	input := "{\"id\": 1, \"name\": \"a\\nb\"}\r\n\n[1, 2]\n{\"id\": 3,}\n\"last\""
+
+	r := NewNDJSONReader(strings.NewReader(input))
+
+	g, err := r.Read()
+	if err != nil || r.Line() != 1 {
+		t.Errorf("%s: Read of line 1 failed with error %v", funcName(), err)
+		return
+	}
+
+	var buf bytes.Buffer
+	w := NewNDJSONWriter(&buf)
+	w.Write(g)
+
+	if g, err = r.Read(); err != nil || r.Line() != 3 {
+		t.Errorf("%s: Read of line 3 failed with error %v at line %d", funcName(), err, r.Line())
+		return
+	}
+	w.Write(g)
+
+	_, err = r.Read()
+	var serr *SyntaxError
+	if !errors.As(err, &serr) {
+		t.Errorf("%s: Read of line 4 returned %v while a SyntaxError was expected", funcName(), err)
+	} else if serr.Line != 4 || serr.Column != 10 || serr.Offset != 44 {
+		t.Errorf("%s: error reported at line %d column %d offset %d", funcName(),
+			serr.Line, serr.Column, serr.Offset)
+	}
+
+	if g, err = r.Read(); err != nil || g.Valstr != "last" {
+		t.Errorf("%s: Read of line 5 failed with error %v", funcName(), err)
+	}
+
+	if _, err = r.Read(); err != io.EOF {
+		t.Errorf("%s: Read at the end returned %v while io.EOF was expected", funcName(), err)
+	}
+
+	if buf.String() != "{\"id\":1,\"name\":\"a\\nb\"}\n[1,2]\n" {
+		t.Errorf("%s: NDJSONWriter wrote %q", funcName(), buf.String())
+	}
+
+	/*
+	 * Skip the bad lines
+	 */
+	var skipped []error
+	r = NewNDJSONReader(strings.NewReader(input))
+	r.SkipBadLines = true
+	r.OnSkip = func(err error) { skipped = append(skipped, err) }
+
+	count := 0
+	for _, err = r.Read(); err == nil; _, err = r.Read() {
+		count++
+	}
+
+	if err != io.EOF || count != 3 || len(skipped) != 1 {
+		t.Errorf("%s: SkipBadLines read %d values and skipped %d, error %v", funcName(),
+			count, len(skipped), err)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	input := `{"a": 1}{"b": [2]}  [3, 4]` + "\n" + `"five" 6 null{}`
+	expected := []struct {
+		output      string
+		offset, end int64
+	}{
+		{`{"a":1}`, 0, 8},
+		{`{"b":[2]}`, 8, 18},
+		{`[3,4]`, 20, 26},
+		{`"five"`, 27, 33},
+		{`6`, 34, 35},
+		{`null`, 36, 40},
+		{`{}`, 40, 42},
+	}
+
+	check := func(name string, docs []Document, err error) {
+		if err != nil {
+			t.Errorf("%s: %s failed with error %s", funcName(), name, err)
+			return
+		}
+
+		if len(docs) != len(expected) {
+			t.Errorf("%s: %s returned %d documents", funcName(), name, len(docs))
+			return
+		}
+
+		for i, doc := range docs {
+			output := string(GoJSONPrintCompact(doc.Value))
+			if output != expected[i].output || doc.Offset != expected[i].offset || doc.End != expected[i].end {
+				t.Errorf("%s: %s document %d is %s at %d-%d", funcName(), name, i, output, doc.Offset, doc.End)
+			}
+		}
+	}
+
+	docs, err := GoJSONParseAll([]byte(input))
+	check("GoJSONParseAll", docs, err)
+
+	docs, err = GoJSONParseAllReader(iotest.OneByteReader(strings.NewReader(input)))
+	check("GoJSONParseAllReader", docs, err)
+
+	docs, err = GoJSONParseAll([]byte(`[1][2][3,]`))
+	if _, ok := err.(*SyntaxError); !ok || len(docs) != 2 {
+		t.Errorf("%s: GoJSONParseAll returned %d documents and error %v", funcName(), len(docs), err)
+	}
+
+	if docs, err = GoJSONParseAll([]byte(" \n ")); err != nil || len(docs) != 0 {
+		t.Errorf("%s: GoJSONParseAll of whitespace returned %d documents and error %v", funcName(), len(docs), err)
+	}
+}
+
+func TestParseIntegers(t *testing.T) {
+	input := []byte(`{"max": 18446744073709551615, "min": -9223372036854775808,
+		"id": 9007199254740993, "ts": 1508932413123456789, "big": 18446744073709551616,
+		"neg": -9223372036854775809, "zero": -0}`)
+
+	g, err := GoJSONParse(input)
+	if err != nil {
+		t.Errorf("%s: GoJSONParse failed with error %s", funcName(), err)
+		return
+	}
+
+	uints := map[string]uint64{
+		"max": 18446744073709551615,
+		"id":  9007199254740993,
+		"ts":  1508932413123456789,
+	}
+
+	for key, val := range uints {
+		if v, err := g.GetUIntVal(key); err != nil || v != val {
+			t.Errorf("%s: key %s returned %d with error %v while expected was %d", funcName(), key, v, err, val)
+		}
+	}
+
+	if v, err := g.GetIntVal("min"); err != nil || v != -9223372036854775808 {
+		t.Errorf("%s: key min returned %d with error %v", funcName(), v, err)
+	}
+
+	if v, err := g.GetIntVal("zero"); err != nil || v != 0 {
+		t.Errorf("%s: key zero returned %d with error %v", funcName(), v, err)
+	}
+
+	/*
+	 * Out of range integers fall back to double by default
+	 */
+	if v, err := g.GetDoubleVal("big"); err != nil || v != 18446744073709551616.0 {
+		t.Errorf("%s: key big returned %f with error %v", funcName(), v, err)
+	}
+
+	if v, err := g.GetDoubleVal("neg"); err != nil || v != -9223372036854775809.0 {
+		t.Errorf("%s: key neg returned %f with error %v", funcName(), v, err)
+	}
+
+	_, err = GoJSONParseWithOptions(input, ParseOptions{IntOverflow: OVERFLOW_ERROR})
+	var serr *SyntaxError
+	if !errors.Is(err, ErrNumberRange) || !errors.As(err, &serr) || serr.Offset != 119 {
+		t.Errorf("%s: OVERFLOW_ERROR returned %v", funcName(), err)
+	}
+}
+
+func TestParseDoubles(t *testing.T) {
+	literals := []string{
+		"0.1", "0.3", "1e+5", "1E-5", "-0.0", "225.1245", "245.67", "2.2250738585072014e-308",
+		"4.9e-324", "1.7976931348623157e308", "123456789012345678901234567890",
+		"0.000000000000000000000000000001", "3.141592653589793238462643383279",
+		"9007199254740993.0", "1e-400", "-123.456e-78",
+	}
+
+	for _, lit := range literals {
+		g, err := GoJSONParse([]byte(lit))
+		if err != nil {
+			t.Errorf("%s: GoJSONParse of %s failed with error %s", funcName(), lit, err)
+			continue
+		}
+
+		expected, _ := strconv.ParseFloat(lit, 64)
+		if g.Jsontype != JSON_DOUBLE || g.Valdouble != expected {
+			t.Errorf("%s: %s was parsed as %v while expected was %v", funcName(), lit, g.Valdouble, expected)
+		}
+
+		/*
+		 * The printed value must parse back to the same double
+		 */
+		f, err := strconv.ParseFloat(string(GoJSONPrint(g)), 64)
+		if err != nil || f != g.Valdouble {
+			t.Errorf("%s: %s didn't round trip through %s", funcName(), lit, GoJSONPrint(g))
+		}
+	}
+
+	for _, lit := range []string{"1e400", "-1.5e309"} {
+		if _, err := GoJSONParse([]byte(lit)); !errors.Is(err, ErrNumberRange) {
+			t.Errorf("%s: GoJSONParse of %s returned %v while ErrNumberRange was expected", funcName(), lit, err)
+		}
+	}
+}
+
+func TestNumberLiteral(t *testing.T) {
+	input := []byte(`{"id":123456789012345678901234567890,"amount":12345678901234567890.10,` +
+		`"small":-1.50e-3,"exp":25E2,"int":7}`)
+
+	g, err := GoJSONParseWithOptions(input, ParseOptions{NumberMode: NUMBER_LITERAL})
+	if err != nil {
+		t.Fatalf("%s: GoJSONParseWithOptions failed with error %s", funcName(), err)
+	}
+
+	/*
+	 * The printer must emit the literals unchanged
+	 */
+	literals := map[string]string{
+		"id":     "123456789012345678901234567890",
+		"amount": "12345678901234567890.10",
+		"small":  "-1.50e-3",
+		"exp":    "25E2",
+	}
+
+	for key, lit := range literals {
+		if output := string(GoJSONPrint(g.GetObjectEntry(key))); output != lit {
+			t.Errorf("%s: %s was printed as %s while expected was %s", funcName(), key, output, lit)
+		}
+	}
+
+	id := g.GetObjectEntry("id")
+	if id.Jsontype != JSON_NUMBER || id.Valnum != "123456789012345678901234567890" {
+		t.Errorf("%s: id was parsed as type %d with literal %q", funcName(), id.Jsontype, id.Valnum)
+	}
+
+	if i, err := id.BigInt(); err != nil || i.String() != "123456789012345678901234567890" {
+		t.Errorf("%s: BigInt returned %v with error %v", funcName(), i, err)
+	}
+
+	amount := g.GetObjectEntry("amount")
+	if _, err := amount.BigInt(); !errors.Is(err, ErrNumberRange) {
+		t.Errorf("%s: BigInt of a fraction returned %v", funcName(), err)
+	}
+
+	if f, err := amount.BigFloat(); err != nil || f.Text('f', 2) != "12345678901234567890.10" {
+		t.Errorf("%s: BigFloat returned %v with error %v", funcName(), f, err)
+	}
+
+	decimals := map[string]string{
+		"amount": "12345678901234567890.10",
+		"small":  "-0.00150",
+		"exp":    "2500",
+		"int":    "7",
+	}
+
+	for key, dec := range decimals {
+		if s := g.GetObjectEntry(key).Decimal(); s != dec {
+			t.Errorf("%s: Decimal of %s returned %s while expected was %s", funcName(), key, s, dec)
+		}
+	}
+
+	if i, err := g.GetObjectEntry("exp").BigInt(); err != nil || i.Int64() != 2500 {
+		t.Errorf("%s: BigInt of 25E2 returned %v with error %v", funcName(), i, err)
+	}
+
+	/*
+	 * Non finite doubles are out of range instead of panicking
+	 */
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		d := &GoJSON{Jsontype: JSON_DOUBLE, Valdouble: f}
+
+		if _, err := d.BigInt(); !errors.Is(err, ErrNumberRange) {
+			t.Errorf("%s: BigInt of %v returned %v", funcName(), f, err)
+		}
+
+		if _, err := d.BigFloat(); !errors.Is(err, ErrNumberRange) {
+			t.Errorf("%s: BigFloat of %v returned %v", funcName(), f, err)
+		}
+	}
+
+	/*
+	 * Non number types are rejected
+	 */
+	if _, err := g.BigInt(); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("%s: BigInt of an object returned %v", funcName(), err)
+	}
+
+	n, err := AllocNumberLiteral("-98765432109876543210")
+	if err != nil || string(GoJSONPrint(n)) != "-98765432109876543210" {
+		t.Errorf("%s: AllocNumberLiteral returned %v with error %v", funcName(), n, err)
+	}
+
+	for _, lit := range []string{"", "01", "1.", "+1", "1e", "1 "} {
+		if _, err := AllocNumberLiteral(lit); err == nil {
+			t.Errorf("%s: AllocNumberLiteral accepted %q", funcName(), lit)
+		}
+	}
+}
+
+func TestKeepNumberLiteral(t *testing.T) {
+	literals := []string{"1.50", "-0.0", "1e5", "2.50E+10", "100", "-7", "0.000100", "18446744073709551616"}
+
+	for _, lit := range literals {
+		g, err := GoJSONParseWithOptions([]byte(lit), ParseOptions{KeepNumberLiteral: true})
+		if err != nil {
+			t.Errorf("%s: GoJSONParseWithOptions of %s failed with error %s", funcName(), lit, err)
+			continue
+		}
+
+		if output := string(GoJSONPrint(g)); output != lit {
+			t.Errorf("%s: %s was printed as %s", funcName(), lit, output)
+		}
+	}
+
+	/*
+	 * The native value is still available
+	 */
+	g, _ := GoJSONParseWithOptions([]byte(`[1.50]`), ParseOptions{KeepNumberLiteral: true})
+	if g.Child.Jsontype != JSON_DOUBLE || g.Child.Valdouble != 1.5 || g.Child.Valnum != "1.50" {
+		t.Errorf("%s: 1.50 was parsed as type %d value %v", funcName(), g.Child.Jsontype, g.Child.Valdouble)
+	}
+
+	/*
+	 * Without the option the literal is lost
+	 */
+	g, _ = GoJSONParse([]byte(`1.50`))
+	if output := string(GoJSONPrint(g)); output != "1.5" {
+		t.Errorf("%s: 1.50 was printed as %s", funcName(), output)
+	}
+
+	/*
+	 * Computed values are printed with the float format
+	 */
+	formats := map[float64]string{
+		1.5:     "1.5",
+		0.1:     "0.1",
+		100:     "100",
+		1e21:    "1e+21",
+		-2.5e-7: "-2.5e-07",
+	}
+
+	for val, expected := range formats {
+		g := AllocNumber(val, JSON_DOUBLE)
+		output := string(GoJSONPrintWithOptions(g, PrintOptions{FloatFormat: FLOAT_SHORTEST}))
+		if output != expected {
+			t.Errorf("%s: %v was printed as %s while expected was %s", funcName(), val, output, expected)
+		}
+	}
+}
+
+func TestFloatFormat(t *testing.T) {
+	formats := map[float64]string{
+		225.1245:               "225.1245",
+		0:                      "0",
+		-0.5:                   "-0.5",
+		100:                    "100",
+		1e20:                   "100000000000000000000",
+		1e21:                   "1e+21",
+		123456789e15:           "1.23456789e+23",
+		0.000001:               "0.000001",
+		0.0000001:              "1e-7",
+		-2.5e-10:               "-2.5e-10",
+		1.7976931348623157e308: "1.7976931348623157e+308",
+		5e-324:                 "5e-324",
+		9007199254740993.0:     "9007199254740992",
+	}
+
+	for val, expected := range formats {
+		if output := string(GoJSONPrint(AllocNumber(val, JSON_DOUBLE))); output != expected {
+			t.Errorf("%s: %v was printed as %s while expected was %s", funcName(), val, output, expected)
+		}
+	}
+
+	if output := string(GoJSONPrint(AllocNumber(math.Copysign(0, -1), JSON_DOUBLE))); output != "0" {
+		t.Errorf("%s: -0 was printed as %s while expected was 0", funcName(), output)
+	}
+
+	/*
+	 * Non finite values are rejected by the builder
+	 */
+	g := AllocObject()
+	for _, val := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if err := g.AddVal(val, "key"); !errors.Is(err, ErrNumberRange) {
+			t.Errorf("%s: AddVal of %v returned %v", funcName(), val, err)
+		}
+
+		if err := g.AddToArray(val, "arr"); !errors.Is(err, ErrNumberRange) {
+			t.Errorf("%s: AddToArray of %v returned %v", funcName(), val, err)
+		}
+	}
+
+	nonFinite := []struct {
+		mode     int
+		expected string
+	}{
+		{NONFINITE_NULL, `[null,null,null]`},
+		{NONFINITE_STRING, `["NaN","Infinity","-Infinity"]`},
+		{NONFINITE_LITERAL, `[NaN,Infinity,-Infinity]`},
+	}
+
+	arr := AllocArray()
+	arr.AddEntryToArray(AllocNumber(math.NaN(), JSON_DOUBLE))
+	arr.AddEntryToArray(AllocNumber(math.Inf(1), JSON_DOUBLE))
+	arr.AddEntryToArray(AllocNumber(math.Inf(-1), JSON_DOUBLE))
+
+	for _, nf := range nonFinite {
+		output := GoJSONPrintWithOptions(arr, PrintOptions{NonFinite: nf.mode})
+		if s := strings.Join(strings.Fields(string(output)), ""); s != nf.expected {
+			t.Errorf("%s: mode %d printed %s while expected was %s", funcName(), nf.mode, s, nf.expected)
+		}
+	}
+
+	/*
+	 * By default non finite values fail the print where
+	 * an error can be returned and print null elsewhere
+	 */
+	if output := string(GoJSONPrintCompact(arr)); output != "[null,null,null]" {
+		t.Errorf("%s: GoJSONPrint of non finite values returned %s", funcName(), output)
+	}
+
+	var buf bytes.Buffer
+	if err := NewNDJSONWriter(&buf).Write(arr); !errors.Is(err, ErrNumberRange) || buf.Len() != 0 {
+		t.Errorf("%s: NDJSONWriter of non finite values returned %v and wrote %q", funcName(), err, buf.String())
+	}
+
+	/*
+	 * A failed Encode leaves nothing buffered in front
+	 * of the next value
+	 */
+	partial := AllocArray()
+	partial.AddEntryToArray(AllocString(strings.Repeat("x", 4092)))
+	partial.AddEntryToArray(AllocNumber(math.Inf(1), JSON_DOUBLE))
+
+	enc := NewEncoderWithOptions(&buf, PrintOptions{Compact: true})
+	if err := enc.Encode(partial); !errors.Is(err, ErrNumberRange) {
+		t.Errorf("%s: Encode of non finite values returned %v", funcName(), err)
+	}
+
+	buf.Reset()
+	if err := enc.Encode(AllocNull()); err != nil || buf.String() != "null" {
+		t.Errorf("%s: Encode after a failed Encode wrote %q with error %v", funcName(), buf.String(), err)
+	}
+
+	/*
+	 * Whole doubles print as integers and are still
+	 * read back by GetDoubleVal
+	 */
+	g = AllocObject()
+	g.AddVal(2.0, "whole")
+
+	parsed, err := GoJSONParse(GoJSONPrint(g))
+	if err != nil {
+		t.Errorf("%s: parsing %s failed with error %s", funcName(), GoJSONPrint(g), err)
+		return
+	}
+
+	if d, err := parsed.GetDoubleVal("whole"); err != nil || d != 2.0 {
+		t.Errorf("%s: GetDoubleVal of a whole double returned %v with error %v", funcName(), d, err)
+	}
+}
+
+func TestPrintCompact(t *testing.T) {
+	input := []byte(`{
+	"outer": {
+		"val1": "foo bar",
+		"val2": [1, -2, 3.5, true, false, null],
+		"val3": {},
+		"val4": [],
+		"val5": [{"a": [[]]}, {}]
+	},
+	"escaped": "line\nbreak"
+}`)
+
+	g, err := GoJSONParse(input)
+	if err != nil {
+		t.Fatalf("%s: GoJSONParse failed with error %s", funcName(), err)
+	}
+
+	expected := `{"outer":{"val1":"foo bar","val2":[1,-2,3.5,true,false,null],"val3":{},` +
+		`"val4":[],"val5":[{"a":[[]]},{}]},"escaped":"line\nbreak"}`
+
+	if output := string(GoJSONPrintCompact(g)); output != expected {
+		t.Errorf("%s: GoJSONPrintCompact returned %s while expected was %s", funcName(), output, expected)
+	}
+
+	output := string(GoJSONPrintWithOptions(g, PrintOptions{Compact: true, EscapeHTML: true}))
+	if output != expected {
+		t.Errorf("%s: GoJSONPrintWithOptions returned %s while expected was %s", funcName(), output, expected)
+	}
+
+	/*
+	 * The compact output must parse back to the same tree
+	 */
+	g2, err := GoJSONParse([]byte(expected))
+	if err != nil || string(GoJSONPrint(g2)) != string(GoJSONPrint(g)) {
+		t.Errorf("%s: compact output didn't round trip", funcName())
+	}
+
+	for _, s := range []string{`{}`, `[]`, `"x"`, `0`} {
+		g, _ := GoJSONParse([]byte(s))
+		if output := string(GoJSONPrintCompact(g)); output != s {
+			t.Errorf("%s: %s was printed as %s", funcName(), s, output)
+		}
+	}
+}
+
+func TestPrintOptions(t *testing.T) {
+	g, err := GoJSONParse([]byte(`{"name":"app","ports":[80,443],"env":{},"tags":[]}`))
+	if err != nil {
+		t.Fatalf("%s: GoJSONParse failed with error %s", funcName(), err)
+	}
+
+	tests := []struct {
+		opts     PrintOptions
+		expected string
+	}{
+		{
+			PrintOptions{},
+			"{\n\t\"name\": \"app\",\n\t\"ports\": [\n\t\t80,\n\t\t443\n\t],\n\t\"env\": {},\n\t\"tags\": []\n}",
+		},
+		{
+			PrintOptions{Indent: "  ", TrailingNewline: true},
+			"{\n  \"name\": \"app\",\n  \"ports\": [\n    80,\n    443\n  ],\n  \"env\": {},\n  \"tags\": []\n}\n",
+		},
+		{
+			PrintOptions{Indent: "  ", Prefix: "# ", KeySeparator: ":"},
+			"# {\n#   \"name\":\"app\",\n#   \"ports\":[\n#     80,\n#     443\n#   ],\n#   \"env\":{},\n#   \"tags\":[]\n# }",
+		},
+		{
+			PrintOptions{Indent: " ", KeySeparator: " : ", EmptyContainers: EMPTY_SPACED},
+			"{\n \"name\" : \"app\",\n \"ports\" : [\n  80,\n  443\n ],\n \"env\" : { },\n \"tags\" : [ ]\n}",
+		},
+		{
+			PrintOptions{Indent: "  ", EmptyContainers: EMPTY_EXPANDED},
+			"{\n  \"name\": \"app\",\n  \"ports\": [\n    80,\n    443\n  ],\n  \"env\": {\n  },\n  \"tags\": [\n  ]\n}",
+		},
+		{
+			PrintOptions{Compact: true, Indent: "  ", KeySeparator: " : ", TrailingNewline: true},
+			"{\"name\":\"app\",\"ports\":[80,443],\"env\":{},\"tags\":[]}\n",
+		},
+	}
+
+	for i, test := range tests {
+		if output := string(GoJSONPrintWithOptions(g, test.opts)); output != test.expected {
+			t.Errorf("%s: test %d returned %q while expected was %q", funcName(), i, output, test.expected)
+		}
+	}
+}
+
+/*
+ * Writer recording the size of each write
+ */
+type chunkWriter struct {
+	bytes.Buffer
+	writes []int
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, len(p))
+	return w.Buffer.Write(p)
+}
+
+/*
+ * Writer failing once n bytes have been written
+ */
+type limitWriter struct {
+	n int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		return 0, io.ErrShortWrite
+	}
+
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestEncoder(t *testing.T) {
+	/*
+	 * Build a tree whose output is much larger than the
+	 * printer chunk size
+	 */
+	g := AllocObject()
+	for i := 0; i < 2000; i++ {
+		if err := g.AddToArray(fmt.Sprintf("item %d", i), "items"); err != nil {
+			t.Fatalf("%s: AddToArray failed with error %s", funcName(), err)
+		}
+	}
+	g.AddVal(1.5, "ratio")
+
+	optsList := []PrintOptions{{}, {Compact: true}, {Indent: "  ", TrailingNewline: true}}
+
+	for _, opts := range optsList {
+		w := &chunkWriter{}
+		e := NewEncoderWithOptions(w, opts)
+
+		for i := 0; i < 2; i++ {
+			if err := e.Encode(g); err != nil {
+				t.Fatalf("%s: Encode failed with error %s", funcName(), err)
+			}
+		}
+
+		expected := GoJSONPrintWithOptions(g, opts)
+		if !bytes.Equal(w.Bytes(), append(append([]byte{}, expected...), expected...)) {
+			t.Errorf("%s: Encode with %+v didn't match GoJSONPrintWithOptions", funcName(), opts)
+		}
+
+		for _, n := range w.writes {
+			if n > 2*4096 {
+				t.Errorf("%s: Encode wrote a chunk of %d bytes", funcName(), n)
+			}
+		}
+	}
+
+	/*
+	 * Errors of the underlying writer are returned
+	 */
+	w := &limitWriter{n: 100}
+	if err := NewEncoder(w).Encode(g); err != io.ErrShortWrite {
+		t.Errorf("%s: Encode returned %v while io.ErrShortWrite was expected", funcName(), err)
+	}
+
+	/*
+	 * AppendJSON appends to the given slice
+	 */
+	dst := []byte("prefix:")
+	dst = AllocNumber(7, JSON_INT).AppendJSON(dst)
+	dst = g.GetObjectEntry("ratio").AppendJSONWithOptions(dst, PrintOptions{FloatFormat: FLOAT_EXPONENT})
+	if string(dst) != "prefix:71.5E+00" {
+		t.Errorf("%s: AppendJSON returned %s", funcName(), dst)
+	}
+}
+
+func TestPrintCanonical(t *testing.T) {
+	/*
+	 * Examples from RFC 8785
+	 */
+	tests := []struct {
+		input, expected string
+	}{
+		{
+			`{"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+			  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+			  "literals": [null, true, false]}`,
+			`{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],` +
+				`"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		{
+			`{"\u20ac": 1, "\r": 2, "\ufb33": 3, "1": 4, "\ud83d\ude00": 5, "\u0080": 6, "\u00f6": 7}`,
+			`{"\r":2,"1":4,"` + "\u0080" + `":6,"ö":7,"€":1,"😀":5,"דּ":3}`,
+		},
+		{
+			`[-0, 0.0, 9007199254740993, -9223372036854775808, 1e21, 1e-7, 123.456e3, {"b": {"d": 1, "c": []}, "a": {}}]`,
+			`[0,0,9007199254740992,-9223372036854776000,1e+21,1e-7,123456,{"a":{},"b":{"c":[],"d":1}}]`,
+		},
+	}
+
+	for _, test := range tests {
+		g, err := GoJSONParse([]byte(test.input))
+		if err != nil {
+			t.Errorf("%s: GoJSONParse of %s failed with error %s", funcName(), test.input, err)
+			continue
+		}
+
+		output, err := GoJSONPrintCanonical(g)
+		if err != nil || string(output) != test.expected {
+			t.Errorf("%s: GoJSONPrintCanonical returned %s (%v) while expected was %s", funcName(), output, err, test.expected)
+		}
+
+		/*
+		 * Number literals and print options don't change
+		 * the canonical form
+		 */
+		g, _ = GoJSONParseWithOptions([]byte(test.input), ParseOptions{KeepNumberLiteral: true})
+		if output, _ := GoJSONPrintCanonical(g); string(output) != test.expected {
+			t.Errorf("%s: GoJSONPrintCanonical with literals returned %s", funcName(), output)
+		}
+
+		g, _ = GoJSONParseWithOptions([]byte(test.input), ParseOptions{NumberMode: NUMBER_LITERAL})
+		if output, _ := GoJSONPrintCanonical(g); string(output) != test.expected {
+			t.Errorf("%s: GoJSONPrintCanonical of NUMBER_LITERAL returned %s", funcName(), output)
+		}
+	}
+
+	/*
+	 * Values without a canonical form are rejected
+	 */
+	g, _ := GoJSONParse([]byte(`{"a": 1, "a": 2}`))
+	if _, err := GoJSONPrintCanonical(g); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("%s: duplicate keys returned %v", funcName(), err)
+	}
+
+	if _, err := GoJSONPrintCanonical(AllocNumber(math.NaN(), JSON_DOUBLE)); !errors.Is(err, ErrNumberRange) {
+		t.Errorf("%s: NaN returned %v", funcName(), err)
+	}
+
+	if _, err := GoJSONPrintCanonical(AllocString("bad \xff")); !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("%s: invalid UTF-8 returned %v", funcName(), err)
+	}
+}
+
+func TestPrintKeyOrder(t *testing.T) {
+	g, err := GoJSONParse([]byte(`{"name":"b","tags":["x"],"id":2,"Zone":{"z":1,"id":0,"a":[{"c":3,"b":2}]},"apple":true}`))
+	if err != nil {
+		t.Fatalf("%s: GoJSONParse failed with error %s", funcName(), err)
+	}
+
+	tests := []struct {
+		opts     PrintOptions
+		expected string
+	}{
+		{
+			PrintOptions{},
+			`{"name":"b","tags":["x"],"id":2,"Zone":{"z":1,"id":0,"a":[{"c":3,"b":2}]},"apple":true}`,
+		},
+		{
+			PrintOptions{SortKeys: true},
+			`{"Zone":{"a":[{"b":2,"c":3}],"id":0,"z":1},"apple":true,"id":2,"name":"b","tags":["x"]}`,
+		},
+		{
+			PrintOptions{KeyLess: func(a, b string) bool { return strings.ToLower(a) < strings.ToLower(b) }},
+			`{"apple":true,"id":2,"name":"b","tags":["x"],"Zone":{"a":[{"b":2,"c":3}],"id":0,"z":1}}`,
+		},
+		{
+			PrintOptions{KeyOrder: []string{"id", "name"}},
+			`{"id":2,"name":"b","tags":["x"],"Zone":{"id":0,"z":1,"a":[{"c":3,"b":2}]},"apple":true}`,
+		},
+		{
+			PrintOptions{KeyOrder: []string{"id", "name"}, SortKeys: true},
+			`{"id":2,"name":"b","Zone":{"id":0,"a":[{"b":2,"c":3}],"z":1},"apple":true,"tags":["x"]}`,
+		},
+	}
+
+	for i, test := range tests {
+		test.opts.Compact = true
+		if output := string(GoJSONPrintWithOptions(g, test.opts)); output != test.expected {
+			t.Errorf("%s: test %d returned %s while expected was %s", funcName(), i, output, test.expected)
+		}
+	}
+
+	/*
+	 * Sorting doesn't modify the tree
+	 */
+	if output := string(GoJSONPrintCompact(g)); output != tests[0].expected {
+		t.Errorf("%s: tree was modified to %s", funcName(), output)
+	}
+
+	output := string(GoJSONPrintWithOptions(g.GetObjectEntry("Zone"), PrintOptions{SortKeys: true, Indent: " "}))
+	expected := "{\n \"a\": [\n  {\n   \"b\": 2,\n   \"c\": 3\n  }\n ],\n \"id\": 0,\n \"z\": 1\n}"
+	if output != expected {
+		t.Errorf("%s: indented output was %q while expected was %q", funcName(), output, expected)
+	}
+}
+
+func TestPrintColors(t *testing.T) {
+	g, err := GoJSONParse([]byte(`{"s":"x","n":[1,2.5],"b":true,"z":null,"e":{}}`))
+	if err != nil {
+		t.Fatalf("%s: GoJSONParse failed with error %s", funcName(), err)
+	}
+
+	expected := `{\x1b[34;1m"s"\x1b[0m:\x1b[32m"x"\x1b[0m,\x1b[34;1m"n"\x1b[0m:[\x1b[36m1\x1b[0m,\x1b[36m2.5\x1b[0m],` +
+		`\x1b[34;1m"b"\x1b[0m:\x1b[33mtrue\x1b[0m,\x1b[34;1m"z"\x1b[0m:\x1b[90mnull\x1b[0m,\x1b[34;1m"e"\x1b[0m:{}}`
+	expected = strings.ReplaceAll(expected, `\x1b`, "\x1b")
+
+	output := string(GoJSONPrintWithOptions(g, PrintOptions{Compact: true, ColorMode: COLOR_ALWAYS}))
+	if output != expected {
+		t.Errorf("%s: colored output was %q while expected was %q", funcName(), output, expected)
+	}
+
+	/*
+	 * Custom colors, empty ones leave the token uncolored
+	 */
+	colors := &Colors{Key: "<k>", Number: "<n>"}
+	output = string(GoJSONPrintWithOptions(g.GetObjectEntry("n"), PrintOptions{ColorMode: COLOR_ALWAYS, Colors: colors}))
+	if output != "[\n\t<n>1\x1b[0m,\n\t<n>2.5\x1b[0m\n]" {
+		t.Errorf("%s: custom colored output was %q", funcName(), output)
+	}
+
+	/*
+	 * Colors are disabled when not writing to a terminal
+	 */
+	plain := string(GoJSONPrintCompact(g))
+	if output := string(GoJSONPrintWithOptions(g, PrintOptions{Compact: true, ColorMode: COLOR_AUTO})); output != plain {
+		t.Errorf("%s: COLOR_AUTO colored a slice: %q", funcName(), output)
+	}
+
+	var buf bytes.Buffer
+	if err := NewEncoderWithOptions(&buf, PrintOptions{Compact: true, ColorMode: COLOR_AUTO}).Encode(g); err != nil || buf.String() != plain {
+		t.Errorf("%s: COLOR_AUTO colored a buffer: %q", funcName(), buf.String())
+	}
+
+	buf.Reset()
+	if err := NewEncoderWithOptions(&buf, PrintOptions{Compact: true, ColorMode: COLOR_ALWAYS}).Encode(g); err != nil || buf.String() != expected {
+		t.Errorf("%s: COLOR_ALWAYS didn't color a buffer: %q", funcName(), buf.String())
+	}
+}
+
+func TestPrintWidth(t *testing.T) {
+	g, err := GoJSONParse([]byte(`{"ids":[1,2,3,4,5],"name":"width","nested":{"a":[],"b":{"c":true}},` +
+		`"long":["aaaaaaaaaa","bbbbbbbbbb","cccccccccc","dddddddddd"],"matrix":[[1,0],[0,1]]}`))
+	if err != nil {
+		t.Fatalf("%s: GoJSONParse failed with error %s", funcName(), err)
+	}
+
+	expected := `{
+  "ids": [1, 2, 3, 4, 5],
+  "name": "width",
+  "nested": {"a": [], "b": {"c": true}},
+  "long": [
+    "aaaaaaaaaa",
+    "bbbbbbbbbb",
+    "cccccccccc",
+    "dddddddddd"
+  ],
+  "matrix": [[1, 0], [0, 1]]
+}`
+
+	output := string(GoJSONPrintWithOptions(g, PrintOptions{Indent: "  ", Width: 40}))
+	if output != expected {
+		t.Errorf("%s: Width 40 printed\n%s\nwhile expected was\n%s", funcName(), output, expected)
+	}
+
+	/*
+	 * The trailing "," counts towards the width. The ids
+	 * line is exactly 25 bytes with it
+	 */
+	output = string(GoJSONPrintWithOptions(g, PrintOptions{Indent: "  ", Width: 24}))
+	if !strings.Contains(output, "\"ids\": [\n") {
+		t.Errorf("%s: Width 24 printed\n%s", funcName(), output)
+	}
+
+	output = string(GoJSONPrintWithOptions(g, PrintOptions{Indent: "  ", Width: 25}))
+	if !strings.Contains(output, "\"ids\": [1, 2, 3, 4, 5],\n") {
+		t.Errorf("%s: Width 25 printed\n%s", funcName(), output)
+	}
+
+	/*
+	 * Everything fits on one line with a large width,
+	 * colors don't count towards it
+	 */
+	flat := `{"ids": [1, 2, 3, 4, 5], "name": "width", "nested": {"a": [], "b": {"c": true}}, ` +
+		`"long": ["aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc", "dddddddddd"], "matrix": [[1, 0], [0, 1]]}`
+
+	if output := string(GoJSONPrintWithOptions(g, PrintOptions{Width: len(flat)})); output != flat {
+		t.Errorf("%s: Width %d printed %s", funcName(), len(flat), output)
+	}
+
+	colors := &Colors{Key: "<k>", Number: "<n>"}
+	output = string(GoJSONPrintWithOptions(g, PrintOptions{Width: len(flat), ColorMode: COLOR_ALWAYS, Colors: colors}))
+	if strings.Contains(output, "\n") {
+		t.Errorf("%s: colored output was broken: %s", funcName(), output)
+	}
+
+	/*
+	 * The streaming printer lays out the same way
+	 */
+	var buf bytes.Buffer
+	opts := PrintOptions{Indent: "  ", Width: 40}
+	if err := NewEncoderWithOptions(&buf, opts).Encode(g); err != nil || buf.String() != expected {
+		t.Errorf("%s: Encode printed\n%s", funcName(), buf.String())
+	}
+}
+
+func TestPrintLimits(t *testing.T) {
+	g := AllocObject()
+	for i := 0; i < 1000; i++ {
+		g.AddToArray(i, "items")
+	}
+	g.AddVal(strings.Repeat("x", 1<<20), "blob")
+	g.AddVal("h"+"\u00e9llo", "short")
+
+	inner, _ := GoJSONParse([]byte(`{"a":{"b":{"c":[1,2]},"e":[]},"k1":1,"k2":2,"k3":3}`))
+	g.AddEntryToObject("deep", inner)
+
+	opts := PrintOptions{Compact: true, MaxDepth: 3, MaxStringLen: 2, MaxMembers: 2}
+	expected := `{"items":[0,1,"…(+998 items)"],"blob":"xx…(+1048574 bytes)","…(+2 keys)":null}`
+	if output := string(GoJSONPrintWithOptions(g, opts)); output != expected {
+		t.Errorf("%s: limited output was %s while expected was %s", funcName(), output, expected)
+	}
+
+	/*
+	 * Strings are cut on a character boundary
+	 */
+	opts.MaxMembers = 0
+	output := string(GoJSONPrintWithOptions(g.GetObjectEntry("short"), opts))
+	if output != `"h…(+5 bytes)"` {
+		t.Errorf("%s: short was printed as %s", funcName(), output)
+	}
+
+	depths := map[int]string{
+		3: `{"a":{"b":{"c":"…(+2 items)"},"e":[]},"k1":1,"k2":2,"k3":3}`,
+		2: `{"a":{"b":"…(+1 keys)","e":[]},"k1":1,"k2":2,"k3":3}`,
+	}
+
+	for depth, expected := range depths {
+		output := string(GoJSONPrintWithOptions(inner, PrintOptions{Compact: true, MaxDepth: depth}))
+		if output != expected {
+			t.Errorf("%s: MaxDepth %d printed %s while expected was %s", funcName(), depth, output, expected)
+		}
+	}
+
+	/*
+	 * Indented and sorted output, the result stays valid JSON
+	 */
+	output = string(GoJSONPrintWithOptions(inner, PrintOptions{Indent: " ", MaxDepth: 1, MaxMembers: 2, SortKeys: true}))
+	expected = "{\n \"a\": \"…(+2 keys)\",\n \"k1\": 1,\n \"…(+2 keys)\": null\n}"
+	if output != expected {
+		t.Errorf("%s: indented output was %q while expected was %q", funcName(), output, expected)
+	}
+
+	if _, err := GoJSONParse([]byte(output)); err != nil {
+		t.Errorf("%s: limited output didn't parse: %s", funcName(), err)
+	}
+}
+
+func TestPointer(t *testing.T) {
+	input := []byte(`{
+   "outer": {
+	"val1": "foo",
+	"val5": [1, 2, {"deep": true}, 4, 5],
+	"a/b": 1,
+	"m~n": 2,
+	"": 3
+  }
+}`)
+
+	g, err := GoJSONParse(input)
+	if err != nil {
+		t.Fatalf("%s: GoJSONParse failed with error %s", funcName(), err)
+	}
+
+	values := map[string]string{
+		"":                   string(GoJSONPrintCompact(g)),
+		"/outer/val1":        `"foo"`,
+		"/outer/val5/0":      `1`,
+		"/outer/val5/2":      `{"deep":true}`,
+		"/outer/val5/2/deep": `true`,
+		"/outer/a~1b":        `1`,
+		"/outer/m~0n":        `2`,
+		"/outer/":            `3`,
+	}
+
+	for ptr, expected := range values {
+		cur, err := g.GetPointer(ptr)
+		if err != nil {
+			t.Errorf("%s: GetPointer(%q) failed with error %s", funcName(), ptr, err)
+			continue
+		}
+
+		if output := string(GoJSONPrintCompact(cur)); output != expected {
+			t.Errorf("%s: GetPointer(%q) returned %s while expected was %s", funcName(), ptr, output, expected)
+		}
+
+		/*
+		 * The pointer of the node leads back to it
+		 */
+		if p, err := g.PointerTo(cur); err != nil || p != ptr {
+			t.Errorf("%s: PointerTo returned %q (%v) while expected was %q", funcName(), p, err, ptr)
+		}
+	}
+
+	errs := map[string]error{
+		"outer":           ErrInvalidPointer,
+		"/outer/m~2n":     ErrInvalidPointer,
+		"/outer/x~":       ErrInvalidPointer,
+		"/outer/missing":  ErrPathNotFound,
+		"/outer/val1/x":   ErrPathNotFound,
+		"/outer/val5/5":   ErrIndexOutOfRange,
+		"/outer/val5/-":   ErrInvalidPointer,
+		"/outer/val5/01":  ErrInvalidPointer,
+		"/outer/val5/-1":  ErrInvalidPointer,
+		"/outer/val5/x/y": ErrInvalidPointer,
+	}
+
+	for ptr, expected := range errs {
+		if _, err := g.GetPointer(ptr); !errors.Is(err, expected) {
+			t.Errorf("%s: GetPointer(%q) returned %v while expected was %v", funcName(), ptr, err, expected)
+		}
+	}
+
+	var perr *PathError
+	if _, err := g.GetPointer("/outer/val5/9"); !errors.As(err, &perr) || strings.Join(perr.Path, "/") != "outer/val5/9" {
+		t.Errorf("%s: GetPointer returned %v", funcName(), err)
+	}
+
+	/*
+	 * Set replaces values in place and appends new ones
+	 */
+	sets := []struct {
+		ptr string
+		val interface{}
+	}{
+		{"/outer/val1", "bar"},
+		{"/outer/val5/1", -20},
+		{"/outer/val5/-", true},
+		{"/outer/val5/6", 7.5},
+		{"/outer/new~1key", uint(9)},
+		{"/outer/val5/2/deep", AllocArray()},
+	}
+
+	for _, set := range sets {
+		if err := g.SetPointer(set.ptr, set.val); err != nil {
+			t.Errorf("%s: SetPointer(%q) failed with error %s", funcName(), set.ptr, err)
+		}
+	}
+
+	if err := g.SetPointer("/outer/val5/9", 1); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("%s: SetPointer past the end returned %v", funcName(), err)
+	}
+
+	if err := g.SetPointer("/missing/key", 1); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("%s: SetPointer with a missing parent returned %v", funcName(), err)
+	}
+
+	/*
+	 * Delete removes object members and array elements
+	 */
+	for _, ptr := range []string{"/outer/a~1b", "/outer/val5/0", "/outer/"} {
+		if err := g.DeletePointer(ptr); err != nil {
+			t.Errorf("%s: DeletePointer(%q) failed with error %s", funcName(), ptr, err)
+		}
+	}
+
+	if err := g.DeletePointer("/outer/a~1b"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("%s: DeletePointer of a deleted key returned %v", funcName(), err)
+	}
+
+	if err := g.DeletePointer(""); !errors.Is(err, ErrInvalidPointer) {
+		t.Errorf("%s: DeletePointer of the root returned %v", funcName(), err)
+	}
+
+	expected := `{"outer":{"val1":"bar","val5":[-20,{"deep":[]},4,5,true,7.5],"m~n":2,"new/key":9}}`
+	if output := string(GoJSONPrintCompact(g)); output != expected {
+		t.Errorf("%s: tree was %s while expected was %s", funcName(), output, expected)
+	}
+
+	if p, err := g.PointerTo(AllocNull()); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("%s: PointerTo of a foreign node returned %q (%v)", funcName(), p, err)
+	}
+
+	if FormatPointer([]string{"a/b", "m~n", "", "0"}) != "/a~1b/m~0n//0" {
+		t.Errorf("%s: FormatPointer returned %s", funcName(), FormatPointer([]string{"a/b", "m~n", "", "0"}))
+	}
+}
+
+func TestPointerMove(t *testing.T) {
+	g, err := GoJSONParse([]byte(`{"a":[1,2,3],"o":{"x":1,"y":2}}`))
+	if err != nil {
+		t.Fatalf("%s: GoJSONParse failed with error %s", funcName(), err)
+	}
+
+	/*
+	 * Nodes of the same tree are copied, leaving the
+	 * source untouched
+	 */
+	moves := [][2]string{{"/b", "/a/0"}, {"/a/-", "/o/x"}, {"/o/z", "/o"}, {"/a/1", "/o/x"}}
+
+	for _, move := range moves {
+		node, err := g.GetPointer(move[1])
+		if err != nil {
+			t.Fatalf("%s: GetPointer(%q) failed with error %s", funcName(), move[1], err)
+		}
+
+		if err := g.SetPointer(move[0], node); err != nil {
+			t.Errorf("%s: SetPointer(%q) failed with error %s", funcName(), move[0], err)
+		}
+	}
+
+	expected := `{"a":[1,1,3,1],"o":{"x":1,"y":2,"z":{"x":1,"y":2}},"b":1}`
+	if output := string(GoJSONPrintCompact(g)); output != expected {
+		t.Errorf("%s: tree was %s while expected was %s", funcName(), output, expected)
+	}
+
+	/*
+	 * Moving a node with DeletePointer after SetPointer
+	 */
+	node, _ := g.GetPointer("/o/z")
+	if err := g.SetPointer("/moved", node); err != nil || g.DeletePointer("/o/z") != nil {
+		t.Errorf("%s: move of /o/z failed with error %v", funcName(), err)
+	}
+
+	expected = `{"a":[1,1,3,1],"o":{"x":1,"y":2},"b":1,"moved":{"x":1,"y":2}}`
+	if output := string(GoJSONPrintCompact(g)); output != expected {
+		t.Errorf("%s: tree was %s while expected was %s", funcName(), output, expected)
+	}
+}
+
+func TestDeleteHead(t *testing.T) {
+	g, err := GoJSONParse([]byte(`{"k":1,"arr":[1,2,3,4]}`))
+	if err != nil {
+		t.Fatalf("%s: GoJSONParse failed with error %s", funcName(), err)
+	}
+
+	/*
+	 * Removing the first element must unlink it from
+	 * the new first element
+	 */
+	if err := g.DeletePointer("/arr/0"); err != nil {
+		t.Fatalf("%s: DeletePointer failed with error %s", funcName(), err)
+	}
+
+	arr := g.GetObjectEntry("arr")
+	if arr.Child.Prev != nil {
+		t.Errorf("%s: new first element still links to the deleted one", funcName())
+	}
+
+	for _, val := range []uint{2, 4} {
+		if err := arr.DelArrayEntry(val, JSON_UINT); err != nil {
+			t.Errorf("%s: DelArrayEntry(%d) failed with error %s", funcName(), val, err)
+		}
+	}
+
+	if err := g.DelEntryFromObject("k"); err != nil || g.Child.Prev != nil {
+		t.Errorf("%s: DelEntryFromObject failed with error %v", funcName(), err)
+	}
+
+	if output := string(GoJSONPrintCompact(g)); output != `{"arr":[3]}` {
+		t.Errorf("%s: tree was %s", funcName(), output)
+	}
+
+	if err := arr.DelArrayEntry(uint(3), JSON_UINT); err != nil || arr.Child != nil {
+		t.Errorf("%s: DelArrayEntry of the only element failed with error %v", funcName(), err)
+	}
+}
+
+func TestNDJSONLongLine(t *testing.T) {
+	long := `{"k":"` + strings.Repeat("x", 10000) + `"}`
+	input := "{\"a\":1}\n" + long + "\n{\"b\":2}\n" + long
+
+	r := NewNDJSONReaderWithOptions(strings.NewReader(input), ParseOptions{MaxInputBytes: 20})
+
+	if g, err := r.Read(); err != nil || g.GetObjectEntry("a") == nil {
+		t.Fatalf("%s: first Read returned error %v", funcName(), err)
+	}
+
+	/*
+	 * The long line is dropped as a whole with a single error
+	 */
+	_, err := r.Read()
+	var serr *SyntaxError
+	if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &serr) || serr.Line != 2 || serr.Offset != 8 {
+		t.Errorf("%s: Read of the long line returned %v", funcName(), err)
+	}
+
+	g, err := r.Read()
+	if err != nil || g.GetObjectEntry("b") == nil || r.Line() != 3 {
+		t.Errorf("%s: Read after the long line returned %v at line %d", funcName(), err, r.Line())
+	}
+
+	/*
+	 * Long lines are skipped with SkipBadLines
+	 */
+	var skipped []int64
+	r = NewNDJSONReaderWithOptions(strings.NewReader(input), ParseOptions{MaxInputBytes: 20})
+	r.SkipBadLines = true
+	r.OnSkip = func(err error) {
+		if errors.As(err, &serr) {
+			skipped = append(skipped, int64(serr.Line), serr.Offset)
+		}
+	}
+
+	var lines []int
+	for {
+		_, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("%s: Read returned error %s", funcName(), err)
+		}
+		lines = append(lines, r.Line())
+	}
+
+	if fmt.Sprint(lines) != "[1 3]" || fmt.Sprint(skipped) != fmt.Sprintf("[2 8 4 %d]", 8+len(long)+1+8) {
+		t.Errorf("%s: read lines %v and skipped %v", funcName(), lines, skipped)
+	}
+}
+
+func TestParseReaderRest(t *testing.T) {
+	inputs := map[string]string{
+		`{"a":1} {"b":2}`: ` {"b":2}`,
+		`12 34`:           ` 34`,
+		`-1.5e3`:          ``,
+		` [true,null]x`:   `x`,
+		`"s"` + "\n":      "\n",
+		`false{}`:         `{}`,
+	}
+
+	for input, rest := range inputs {
+		readers := map[string]io.Reader{
+			"strings.Reader": strings.NewReader(input),
+			"bytes.Reader":   bytes.NewReader([]byte(input)),
+		}
+
+		/*
+		 * An io.ByteScanner that isn't an io.Seeker
+		 */
+		bs := strings.NewReader(input)
+		readers["ByteScanner"] = struct {
+			io.ByteScanner
+			io.Reader
+		}{bs, bs}
+
+		for name, r := range readers {
+			if _, err := GoJSONParseReader(r); err != nil {
+				t.Errorf("%s: GoJSONParseReader of %q from %s failed with error %s", funcName(), input, name, err)
+				continue
+			}
+
+			if left, _ := io.ReadAll(r); string(left) != rest {
+				t.Errorf("%s: %s left %q after %q while expected was %q", funcName(), name, left, input, rest)
+			}
+		}
+	}
+
+	/*
+	 * Reading one byte at a time stays linear for long strings
+	 */
+	long := `"` + strings.Repeat("x", 1<<20) + `" 1`
+	bs := strings.NewReader(long)
+	g, err := GoJSONParseReader(struct {
+		io.ByteScanner
+		io.Reader
+	}{bs, bs})
+	if err != nil || len(g.Valstr) != 1<<20 {
+		t.Errorf("%s: GoJSONParseReader of a long string failed with error %v", funcName(), err)
+	}
+
+	if left, _ := io.ReadAll(bs); string(left) != " 1" {
+		t.Errorf("%s: long string left %q", funcName(), left)
+	}
+
+	/*
+	 * GoJSONWalkReader leaves the rest of the stream too
+	 */
+	r := strings.NewReader(`[1,2] 3`)
+	if err := GoJSONWalkReader(r, &recordHandler{}); err != nil {
+		t.Errorf("%s: GoJSONWalkReader failed with error %s", funcName(), err)
+	}
+
+	if left, _ := io.ReadAll(r); string(left) != " 3" {
+		t.Errorf("%s: GoJSONWalkReader left %q", funcName(), left)
+	}
+}
+
+func TestPrintWidthLimits(t *testing.T) {
+	g := AllocObject()
+	g.AddVal(strings.Repeat("x", 100), "a")
+
+	opts := PrintOptions{Width: 60, MaxStringLen: 5}
+	if output := string(GoJSONPrintWithOptions(g, opts)); output != `{"a": "xxxxx…(+95 bytes)"}` {
+		t.Errorf("%s: MaxStringLen printed %q", funcName(), output)
+	}
+
+	arr := AllocArray()
+	for i := 0; i < 1000; i++ {
+		arr.AddEntryToArray(AllocNumber(1, JSON_UINT))
+	}
+
+	opts = PrintOptions{Width: 60, MaxMembers: 3}
+	if output := string(GoJSONPrintWithOptions(arr, opts)); output != `[1, 1, 1, "…(+997 items)"]` {
+		t.Errorf("%s: MaxMembers printed %q", funcName(), output)
+	}
+
+	deep, _ := GoJSONParse([]byte(`{"k":[[1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20]],"o":{"x":1,"y":2,"z":3}}`))
+
+	expected := `{"k": ["…(+20 items)"], "o": {"x": 1, "y": 2, "…(+1 keys)": null}}`
+	opts = PrintOptions{Width: len(expected), MaxDepth: 2, MaxMembers: 2}
+	if output := string(GoJSONPrintWithOptions(deep, opts)); output != expected {
+		t.Errorf("%s: MaxDepth printed %q while expected was %q", funcName(), output, expected)
+	}
+
+	/*
+	 * The elided width is exact
+	 */
+	opts.Width = len(expected) - 1
+	if output := string(GoJSONPrintWithOptions(deep, opts)); !strings.Contains(output, "\n") {
+		t.Errorf("%s: Width %d printed %q on one line", funcName(), opts.Width, output)
+	}
+
+	/*
+	 * Sorted members are measured in printed order
+	 */
+	sorted, _ := GoJSONParse([]byte(`{"z":"short","a":"a-much-longer-string-value-here","m":1}`))
+
+	sortOpts := PrintOptions{Width: 40, SortKeys: true, MaxMembers: 1}
+	for _, line := range strings.Split(string(GoJSONPrintWithOptions(sorted, sortOpts)), "\n") {
+		if len(line) > sortOpts.Width {
+			t.Errorf("%s: sorted members printed %q past Width %d", funcName(), line, sortOpts.Width)
+		}
+	}
+
+	sortOpts.Width = 62
+	if output := string(GoJSONPrintWithOptions(sorted, sortOpts)); output != `{"a": "a-much-longer-string-value-here", "…(+2 keys)": null}` {
+		t.Errorf("%s: sorted members printed %q", funcName(), output)
+	}
+}
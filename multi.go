@@ -0,0 +1,70 @@
+package jsonez
+
+import (
+	"io"
+)
+
+/**
+ * Parsing of streams holding several concatenated
+ * top level JSON values, e.g. {...}{...}[...]
+ */
+
+/*
+ * Document is a top level value from a stream
+ * of concatenated values
+ */
+type Document struct {
+	Value *GoJSON
+
+	/**
+	 * Offsets in the input of the first byte of the
+	 * value and of the byte following it
+	 */
+	Offset, End int64
+}
+
+/**
+ * Function to parse the top level values until
+ * the input is exhausted
+ */
+func (p *parser) parseAll() ([]Document, error) {
+	var docs []Document
+
+	for p.nextToken() == true {
+		doc := Document{Value: new(GoJSON), Offset: p.base + int64(p.pos)}
+
+		if err := p.parseValue(doc.Value); err != nil {
+			return docs, err
+		}
+
+		doc.End = p.base + int64(p.pos)
+		docs = append(docs, doc)
+	}
+
+	if p.rerr != nil && p.rerr != io.EOF {
+		return docs, p.rerr
+	}
+
+	return docs, nil
+}
+
+/*
+ * Function to parse all the consecutive top level values
+ * in input. Values may be separated by whitespace or
+ * directly follow each other. On error the values parsed
+ * before the failing one are returned along with the error
+ */
+func GoJSONParseAll(input []byte) ([]Document, error) {
+	return newParser(input, ParseOptions{}).parseAll()
+}
+
+/*
+ * Function to parse all the consecutive top level
+ * values read from r until the end of the stream
+ */
+func GoJSONParseAllReader(r io.Reader) ([]Document, error) {
+	p := newReaderParser(r, ParseOptions{})
+	defer p.release()
+
+	return p.parseAll()
+}
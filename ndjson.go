@@ -0,0 +1,207 @@
+package jsonez
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+/**
+ * Reading and writing newline delimited JSON
+ * (NDJSON / JSON Lines), one value per line
+ */
+
+/*
+ * NDJSONReader reads one GoJSON value per line
+ */
+type NDJSONReader struct {
+	/**
+	 * Skip the lines that fail to parse instead of
+	 * returning their error from Read
+	 */
+	SkipBadLines bool
+
+	/**
+	 * Optional function called with the error of
+	 * each line skipped due to SkipBadLines
+	 */
+	OnSkip func(err error)
+
+	rd   *bufio.Reader
+	opts ParseOptions
+
+	/** Number of the last line read and offset of the next one */
+	line   int
+	offset int64
+
+	buf []byte
+}
+
+/*
+ * Function to create an NDJSONReader reading from r
+ */
+func NewNDJSONReader(r io.Reader) *NDJSONReader {
+	return NewNDJSONReaderWithOptions(r, ParseOptions{})
+}
+
+/*
+ * Function to create an NDJSONReader applying the limits
+ * and policies in opts to each line. MaxInputBytes limits
+ * the length of a line
+ */
+func NewNDJSONReaderWithOptions(r io.Reader, opts ParseOptions) *NDJSONReader {
+	return &NDJSONReader{rd: bufio.NewReaderSize(r, readBufferSize), opts: opts}
+}
+
+/*
+ * Line returns the number of the line holding the
+ * value returned by the last call to Read
+ */
+func (n *NDJSONReader) Line() int {
+	return n.line
+}
+
+/**
+ * Function to read the next line into buf, without
+ * the line terminator. Also returns the number of bytes
+ * consumed. A line longer than MaxInputBytes is read to
+ * its end and dropped
+ */
+func (n *NDJSONReader) readLine() ([]byte, int64, error) {
+	var size int64
+	var tooLong bool
+
+	n.buf = n.buf[:0]
+
+	for {
+		chunk, err := n.rd.ReadSlice('\n')
+		size += int64(len(chunk))
+
+		if !tooLong {
+			n.buf = append(n.buf, chunk...)
+
+			if n.opts.MaxInputBytes > 0 && len(n.buf) > n.opts.MaxInputBytes+2 {
+				tooLong = true
+				n.buf = n.buf[:0]
+			}
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		} else if err == io.EOF && size > 0 {
+			break
+		} else if err != nil {
+			return nil, size, err
+		}
+
+		break
+	}
+
+	if tooLong {
+		serr := newSyntaxError(nil, 0,
+			fmt.Sprintf("line exceeds max size of %d bytes", n.opts.MaxInputBytes), "")
+		serr.Err = ErrLimitExceeded
+		return nil, size, serr
+	}
+
+	line := bytes.TrimSuffix(n.buf, []byte{'\n'})
+	return bytes.TrimSuffix(line, []byte{'\r'}), size, nil
+}
+
+/*
+ * Read returns the value on the next non blank line.
+ * Syntax errors are reported with the line number and
+ * the offset in the whole stream. io.EOF is returned
+ * at the end of the input
+ */
+func (n *NDJSONReader) Read() (*GoJSON, error) {
+	for {
+		var serr *SyntaxError
+
+		start := n.offset
+		line, size, err := n.readLine()
+
+		if err != nil && !errors.As(err, &serr) {
+			return nil, err
+		}
+
+		n.line++
+		n.offset += size
+
+		if err != nil {
+			serr.Line, serr.Column, serr.Offset = n.line, 1, start
+
+			if n.SkipBadLines {
+				if n.OnSkip != nil {
+					n.OnSkip(err)
+				}
+				continue
+			}
+
+			return nil, err
+		}
+
+		if len(bytes.Trim(line, " \t")) == 0 {
+			continue
+		}
+
+		g, err := GoJSONParseWithOptions(line, n.opts)
+
+		if err != nil {
+			if errors.As(err, &serr) {
+				serr.Line = n.line
+				serr.Offset += start
+			}
+
+			if n.SkipBadLines {
+				if n.OnSkip != nil {
+					n.OnSkip(err)
+				}
+				continue
+			}
+
+			return nil, err
+		}
+
+		return g, nil
+	}
+}
+
+/*
+ * NDJSONWriter writes GoJSON values as compact JSON,
+ * one value per line
+ */
+type NDJSONWriter struct {
+	/** Options used to print the values */
+	Options PrintOptions
+
+	w   io.Writer
+	buf []byte
+}
+
+/*
+ * Function to create an NDJSONWriter writing to w
+ */
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{w: w}
+}
+
+/*
+ * Write writes g followed by a newline with a
+ * single call to the underlying writer. Nothing is
+ * written if g can't be printed
+ */
+func (n *NDJSONWriter) Write(g *GoJSON) error {
+	opts := n.Options
+	opts.Compact, opts.TrailingNewline = true, true
+
+	var err error
+	if n.buf, err = g.appendJSON(n.buf[:0], &opts); err != nil {
+		return err
+	}
+
+	_, err = n.w.Write(n.buf)
+	return err
+}
@@ -0,0 +1,200 @@
+package jsonez
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+/**
+ * Arbitrary precision access to numbers. Numbers parsed
+ * with NUMBER_LITERAL keep the literal text and are
+ * converted only on access, so no precision is lost
+ */
+
+/**
+ * Max magnitude of the exponent of a number literal
+ * converted to an exact value. Larger exponents would
+ * need huge amounts of memory
+ */
+const maxLiteralExp = 1 << 16
+
+/**
+ * Function to split a number literal into its sign,
+ * digits and the position of the decimal point
+ * relative to the start of the digits
+ */
+func splitLiteral(lit string) (string, string, int, error) {
+	var sign, mant string
+	var exp int
+
+	if strings.HasPrefix(lit, "-") {
+		sign = "-"
+		lit = lit[1:]
+	}
+
+	mant = lit
+
+	if i := strings.IndexAny(lit, "eE"); i >= 0 {
+		e, err := strconv.Atoi(lit[i+1:])
+
+		if err != nil || e > maxLiteralExp || e < -maxLiteralExp {
+			return "", "", 0, fmt.Errorf("jsonez: %w: exponent of %s", ErrNumberRange, lit)
+		}
+
+		mant, exp = lit[:i], e
+	}
+
+	point := len(mant)
+
+	if i := strings.IndexByte(mant, '.'); i >= 0 {
+		point = i
+		mant = mant[:i] + mant[i+1:]
+	}
+
+	return sign, mant, point + exp, nil
+}
+
+/**
+ * Function to convert a number literal to a plain decimal
+ * string without an exponent. The digits of the literal
+ * are kept, including trailing zeros of the fraction
+ */
+func decimalString(lit string) (string, error) {
+	if strings.IndexAny(lit, "eE") < 0 {
+		return lit, nil
+	}
+
+	sign, digits, point, err := splitLiteral(lit)
+
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case point <= 0:
+		digits = "0." + strings.Repeat("0", -point) + digits
+	case point >= len(digits):
+		digits = digits + strings.Repeat("0", point-len(digits))
+	default:
+		digits = digits[:point] + "." + digits[point:]
+	}
+
+	/*
+	 * Drop the leading zeros of the integer part
+	 */
+	for len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		digits = digits[1:]
+	}
+
+	return sign + digits, nil
+}
+
+/**
+ * Method to get the value of an integer number as a
+ * *big.Int. Fails with ErrNumberRange if the number has
+ * a fractional part
+ */
+func (g *GoJSON) BigInt() (*big.Int, error) {
+	switch g.Jsontype {
+	case JSON_INT:
+		return big.NewInt(g.Valint), nil
+
+	case JSON_UINT:
+		return new(big.Int).SetUint64(g.Valuint), nil
+
+	case JSON_DOUBLE:
+		if math.IsNaN(g.Valdouble) || math.IsInf(g.Valdouble, 0) {
+			return nil, fmt.Errorf("jsonez: %w: %v is not an integer", ErrNumberRange, g.Valdouble)
+		}
+
+		f := big.NewFloat(g.Valdouble)
+
+		if f.IsInt() {
+			i, _ := f.Int(nil)
+			return i, nil
+		}
+
+	case JSON_NUMBER:
+		if _, _, _, err := splitLiteral(g.Valnum); err != nil {
+			return nil, err
+		}
+
+		if r, ok := new(big.Rat).SetString(g.Valnum); ok && r.IsInt() {
+			return r.Num(), nil
+		}
+
+	default:
+		return nil, typeError(nil, JSON_NUMBER, g.Jsontype)
+	}
+
+	return nil, fmt.Errorf("jsonez: %w: %s is not an integer", ErrNumberRange, g.Decimal())
+}
+
+/**
+ * Method to get the value of a number as a *big.Float
+ * with enough precision to hold all the digits of the
+ * number literal. Fails with ErrNumberRange for NaN and
+ * infinite doubles
+ */
+func (g *GoJSON) BigFloat() (*big.Float, error) {
+	switch g.Jsontype {
+	case JSON_INT:
+		return new(big.Float).SetInt64(g.Valint), nil
+
+	case JSON_UINT:
+		return new(big.Float).SetUint64(g.Valuint), nil
+
+	case JSON_DOUBLE:
+		if math.IsNaN(g.Valdouble) || math.IsInf(g.Valdouble, 0) {
+			return nil, fmt.Errorf("jsonez: %w: %v is not a finite number", ErrNumberRange, g.Valdouble)
+		}
+
+		return big.NewFloat(g.Valdouble), nil
+
+	case JSON_NUMBER:
+		/*
+		 * Each decimal digit takes less than 4 bits
+		 */
+		prec := uint(4*len(g.Valnum) + 64)
+
+		f, _, err := big.ParseFloat(g.Valnum, 10, prec, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("jsonez: %w: %s", ErrNumberRange, err)
+		}
+
+		return f, nil
+	}
+
+	return nil, typeError(nil, JSON_NUMBER, g.Jsontype)
+}
+
+/**
+ * Method to get a number as a plain decimal string
+ * without an exponent. For JSON_NUMBER the exact digits
+ * of the literal are kept. An empty string is returned
+ * for other types
+ */
+func (g *GoJSON) Decimal() string {
+	switch g.Jsontype {
+	case JSON_INT:
+		return strconv.FormatInt(g.Valint, 10)
+
+	case JSON_UINT:
+		return strconv.FormatUint(g.Valuint, 10)
+
+	case JSON_DOUBLE:
+		return strconv.FormatFloat(g.Valdouble, 'f', -1, 64)
+
+	case JSON_NUMBER:
+		if s, err := decimalString(g.Valnum); err == nil {
+			return s
+		}
+
+		return g.Valnum
+	}
+
+	return ""
+}
@@ -1,11 +1,16 @@
 package jsonez
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 /*
@@ -20,10 +25,9 @@ const (
 	JSON_STRING
 	JSON_ARRAY
 	JSON_OBJECT
+	JSON_NUMBER
 )
 
-var errorOffset int = -1
-
 /*
  * GoJSON structure
  */
@@ -71,6 +75,14 @@ type GoJSON struct {
 	 */
 	Valbool bool
 
+	/**
+	 * Valnum will be set to the number literal
+	 * when type is JSON_NUMBER, or for any number
+	 * parsed with KeepNumberLiteral. When set it is
+	 * printed instead of Valint, Valuint or Valdouble
+	 */
+	Valnum string
+
 	/**
 	 * JSON Key
 	 */
@@ -81,174 +93,671 @@ type GoJSON struct {
  * Functions related to parsing a JSON string
  */
 
+/*
+ * Policies for handling duplicate keys in an object
+ */
+const (
+	/** Keep all the entries */
+	DUPKEY_ALLOW = iota
+
+	/** Fail the parse with ErrDuplicateKey */
+	DUPKEY_REJECT
+
+	/** Keep the first entry and drop the later ones */
+	DUPKEY_FIRST
+
+	/** Keep the value of the last entry */
+	DUPKEY_LAST
+)
+
+/*
+ * Policies for handling integers out of the 64 bit range
+ */
+const (
+	/** Parse the integer as a JSON_DOUBLE */
+	OVERFLOW_DOUBLE = iota
+
+	/** Fail the parse with ErrNumberRange */
+	OVERFLOW_ERROR
+)
+
+/*
+ * Modes for storing parsed numbers
+ */
+const (
+	/** Store numbers as JSON_INT, JSON_UINT or JSON_DOUBLE */
+	NUMBER_NATIVE = iota
+
+	/**
+	 * Store numbers as JSON_NUMBER keeping the literal
+	 * text, for values beyond the int64 or double precision
+	 */
+	NUMBER_LITERAL
+)
+
+/*
+ * Default limit on the nesting depth of arrays and objects
+ */
+const DEFAULT_MAX_DEPTH = 10000
+
+/*
+ * Options to control the parser. The zero value
+ * applies DEFAULT_MAX_DEPTH and no other limits
+ */
+type ParseOptions struct {
+	/**
+	 * Max nesting depth of arrays and objects. 0 selects
+	 * DEFAULT_MAX_DEPTH and a negative value disables the limit
+	 */
+	MaxDepth int
+
+	/** Max size of the input in bytes, 0 for no limit */
+	MaxInputBytes int
+
+	/** Max length of a decoded string or key in bytes, 0 for no limit */
+	MaxStringLen int
+
+	/** Max number of entries in an array or object, 0 for no limit */
+	MaxMembers int
+
+	/** Duplicate key policy, one of the DUPKEY_* values */
+	DuplicateKeys int
+
+	/**
+	 * Policy for integers that don't fit in an int64 or
+	 * uint64, one of the OVERFLOW_* values
+	 */
+	IntOverflow int
+
+	/** How numbers are stored, one of the NUMBER_* values */
+	NumberMode int
+
+	/**
+	 * Keep the literal text of native numbers in Valnum
+	 * so that they are printed back byte for byte
+	 */
+	KeepNumberLiteral bool
+}
+
+/*
+ * Parser state while walking the input
+ */
+type parser struct {
+	/**
+	 * Input being parsed. When reading from a stream
+	 * this holds the unconsumed part of the input read
+	 * so far
+	 */
+	data []byte
+
+	/** Offset of the next byte to be processed */
+	pos int
+
+	opts ParseOptions
+
+	/** Current and max nesting depth */
+	depth, maxDepth int
+
+	/**
+	 * Stream the input is read from, nil when
+	 * parsing a byte slice
+	 */
+	rd *bufio.Reader
+
+	/**
+	 * Reader wrapped by rd that the bytes read past the
+	 * value are handed back to, nil if not supported
+	 */
+	src io.Reader
+
+	/**
+	 * Number of bytes at the end of data that were
+	 * peeked but not yet discarded from rd
+	 */
+	pending int
+
+	/** Error from the last read from rd */
+	rerr error
+
+	/**
+	 * Stream offset, line number and line start offset
+	 * of data[0], used to locate errors
+	 */
+	base, lineStart int64
+	line            int
+}
+
+/**
+ * Function to create a parser for input
+ */
+func newParser(input []byte, opts ParseOptions) *parser {
+	p := &parser{data: input, opts: opts, maxDepth: opts.MaxDepth}
+
+	if p.maxDepth == 0 {
+		p.maxDepth = DEFAULT_MAX_DEPTH
+	} else if p.maxDepth < 0 {
+		p.maxDepth = math.MaxInt
+	}
+
+	return p
+}
+
 /**
- * nextToken finds the next token
+ * Function to create a SyntaxError at the given offset
+ * in data, adjusting its location for the stream
+ * data that was already discarded
  */
-func nextToken(input []byte) []byte {
-	for i, c := range input {
-		if unicode.IsSpace(rune(c)) || c == '\'' {
-			continue
-		} else {
-			return input[i:]
-		}
+func (p *parser) syntaxError(offset int, msg, expected string) *SyntaxError {
+	serr := newSyntaxError(p.data, offset, msg, expected)
+
+	if serr.Line == 1 {
+		serr.Column = int(p.base+serr.Offset-p.lineStart) + 1
 	}
 
-	return []byte{}
+	serr.Offset += p.base
+	serr.Line += p.line
+
+	return serr
 }
 
 /**
- * Function to parse a string
+ * Function to create a SyntaxError at the given offset
+ */
+func (p *parser) errorAt(offset int, msg, expected string) error {
+	return p.syntaxError(offset, msg, expected)
+}
+
+/**
+ * Function to create a SyntaxError at the given offset
+ * wrapping err
  */
-func parseString(cur *GoJSON, input []byte) ([]byte, error) {
-	var offset int
+func (p *parser) errorWrap(offset int, msg, expected string, err error) error {
+	serr := p.syntaxError(offset, msg, expected)
+	serr.Err = err
 
-	if len(input) == 0 {
-		errorStr := fmt.Sprintf("%s: Byte slice is empty", funcName())
-		return []byte{}, errors.New(errorStr)
+	return serr
+}
+
+/**
+ * Function to report an unexpected byte or the end
+ * of the input at offset
+ */
+func (p *parser) errorAtEnd(offset int, expected string) error {
+	if p.ensure(offset-p.pos+1) == false {
+		return p.endOfInput(offset, expected)
 	}
 
-	if input[0] != '"' {
-		errorStr := fmt.Sprintf("%s: Not a valid string in input %s", funcName(), string(input))
-		return nil, errors.New(errorStr)
+	return p.errorAt(offset, "invalid character "+quoteChar(p.data[offset]), expected)
+}
+
+/**
+ * Function to report the input ending at offset. Read
+ * errors from the stream are returned as is
+ */
+func (p *parser) endOfInput(offset int, expected string) error {
+	if p.rerr != nil && p.rerr != io.EOF {
+		return p.rerr
 	}
 
-	for i := range input {
-		if i > 0 && input[i] == '"' {
-			break
-		} else {
-			offset++
+	return p.errorAt(offset, "unexpected end of input", expected)
+}
+
+/**
+ * Function to create a SyntaxError for an exceeded limit
+ */
+func (p *parser) limitError(offset int, msg string) error {
+	return p.errorWrap(offset, msg, "", ErrLimitExceeded)
+}
+
+/**
+ * Function to create a SyntaxError for the current byte
+ */
+func (p *parser) unexpected(expected string) error {
+	return p.errorAtEnd(p.pos, expected)
+}
+
+/**
+ * Function to make at least n bytes available at the
+ * current offset, reading more input from the stream
+ * if required. Returns false if the input ends first
+ */
+func (p *parser) ensure(n int) bool {
+	for len(p.data)-p.pos < n {
+		if p.fill() == false {
+			return false
 		}
 	}
 
-	cur.Jsontype = JSON_STRING
-	cur.Valstr = strings.Trim(string(input[1:offset]), " ")
+	return true
+}
 
-	return input[offset+1:], nil
+/**
+ * Function to check if the next bytes match the given literal
+ */
+func (p *parser) hasPrefix(lit string) bool {
+	return p.ensure(len(lit)) && string(p.data[p.pos:p.pos+len(lit)]) == lit
 }
 
 /**
- * Function to parse a number
+ * nextToken moves past whitespace to the next token
+ * and returns false if the input is exhausted. Only
+ * the whitespace allowed by RFC 8259 is skipped
  */
-func parseNumber(cur *GoJSON, input []byte) ([]byte, error) {
-	var n, sign, scale float64
-	var subscale, signsubscale, offset int
-	var isDouble bool = false
+func (p *parser) nextToken() bool {
+	for p.ensure(1) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return true
+		}
+	}
 
-	sign = 1
-	subscale = 0
-	signsubscale = 1
+	return false
+}
 
-	if len(input) == 0 {
-		errorStr := fmt.Sprintf("%s: Byte slice is empty", funcName())
-		return []byte{}, errors.New(errorStr)
+/**
+ * Function to decode the 4 hex digits of a \u escape.
+ * Returns -1 if the input is not a valid hex sequence
+ */
+func getHex4(input []byte) rune {
+	var r rune
+
+	if len(input) < 4 {
+		return -1
 	}
 
-	if input[offset] == '-' {
-		sign = -1
-		offset++
+	for _, c := range input[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			c = c - '0'
+		case c >= 'a' && c <= 'f':
+			c = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return -1
+		}
+		r = r*16 + rune(c)
 	}
 
-	if unicode.IsDigit(rune(input[offset])) == false {
-		errorStr := fmt.Sprintf("%s: Not a valid number in input %s", funcName(), string(input))
-		return nil, errors.New(errorStr)
+	return r
+}
+
+/**
+ * Function to decode a quoted JSON string. input[0] must be
+ * the opening quote. Returns the decoded string and the number
+ * of bytes consumed including both the quotes. On failure the
+ * offset of the offending byte is returned along with the error
+ */
+func unquoteString(input []byte) (string, int, error) {
+	var output []byte
+	var offset int = 1
+
+	/*
+	 * Fast path: no escapes and only printable ASCII,
+	 * the string can be returned as is
+	 */
+	for offset < len(input) {
+		c := input[offset]
+
+		if c == '"' {
+			return string(input[1:offset]), offset + 1, nil
+		}
+
+		if c == '\\' || c < 0x20 || c >= utf8.RuneSelf {
+			break
+		}
+		offset++
 	}
 
-	for {
-		if input[offset] == '0' {
+	output = make([]byte, 0, len(input[1:offset])+16)
+	output = append(output, input[1:offset]...)
+
+	for offset < len(input) {
+		c := input[offset]
+
+		switch {
+		case c == '"':
+			return string(output), offset + 1, nil
+
+		case c < 0x20:
+			return "", offset, fmt.Errorf("invalid control character 0x%02x in string", c)
+
+		case c == '\\':
+			if offset+1 >= len(input) {
+				return "", offset, errors.New("unterminated string")
+			}
+
+			switch input[offset+1] {
+			case '"', '\\', '/':
+				output = append(output, input[offset+1])
+			case 'b':
+				output = append(output, '\b')
+			case 'f':
+				output = append(output, '\f')
+			case 'n':
+				output = append(output, '\n')
+			case 'r':
+				output = append(output, '\r')
+			case 't':
+				output = append(output, '\t')
+			case 'u':
+				r := getHex4(input[offset+2:])
+				if r < 0 {
+					return "", offset, errors.New("invalid unicode escape in string")
+				}
+				offset += 6
+
+				/*
+				 * Characters outside the BMP are encoded as a
+				 * UTF-16 surrogate pair, combine them. A lone
+				 * surrogate is replaced with U+FFFD
+				 */
+				if utf16.IsSurrogate(r) {
+					r2 := rune(-1)
+					if len(input) > offset+1 && input[offset] == '\\' && input[offset+1] == 'u' {
+						r2 = getHex4(input[offset+2:])
+					}
+
+					if dec := utf16.DecodeRune(r, r2); dec != unicode.ReplacementChar {
+						r = dec
+						offset += 6
+					} else {
+						r = unicode.ReplacementChar
+					}
+				}
+
+				output = utf8.AppendRune(output, r)
+				continue
+			default:
+				return "", offset, fmt.Errorf("invalid escape sequence \\%c in string", input[offset+1])
+			}
+			offset += 2
+
+		case c < utf8.RuneSelf:
+			output = append(output, c)
 			offset++
-		} else {
-			break
+
+		default:
+			r, size := utf8.DecodeRune(input[offset:])
+			if r == utf8.RuneError && size == 1 {
+				return "", offset, errors.New("invalid UTF-8 sequence in string")
+			}
+			output = append(output, input[offset:offset+size]...)
+			offset += size
 		}
 	}
 
+	return "", offset, errors.New("unterminated string")
+}
+
+/**
+ * Function to parse a string
+ */
+func (p *parser) parseString(cur *GoJSON) error {
+	var end int = 1
+
+	if p.ensure(1) == false || p.data[p.pos] != '"' {
+		return p.unexpected("string")
+	}
+
+	/*
+	 * Find the closing quote so that the whole
+	 * string is available for decoding
+	 */
 	for {
-		if unicode.IsDigit(rune(input[offset])) == true {
-			n = (n * 10.0) + float64(input[offset]-'0')
-			offset++
-		} else {
+		if p.ensure(end+1) == false {
+			return p.endOfInput(p.pos+end, "'\"'")
+		}
+
+		c := p.data[p.pos+end]
+
+		if c == '"' {
 			break
+		} else if c == '\\' {
+			end += 2
+		} else {
+			end++
+		}
+
+		/*
+		 * A decoded byte takes at most 6 bytes of input
+		 */
+		if p.opts.MaxStringLen > 0 && end > 6*p.opts.MaxStringLen+1 {
+			return p.limitError(p.pos, fmt.Sprintf("string exceeds max length of %d bytes", p.opts.MaxStringLen))
 		}
 	}
 
-	if input[offset] == '.' && unicode.IsDigit(rune(input[offset+1])) == true {
-		offset++
+	str, offset, err := unquoteString(p.data[p.pos : p.pos+end+1])
+
+	if err != nil {
+		return p.errorAt(p.pos+offset, err.Error(), "")
+	}
+
+	if p.opts.MaxStringLen > 0 && len(str) > p.opts.MaxStringLen {
+		return p.limitError(p.pos, fmt.Sprintf("string exceeds max length of %d bytes", p.opts.MaxStringLen))
+	}
+
+	cur.Jsontype = JSON_STRING
+	cur.Valstr = str
+	p.pos += offset
+
+	return nil
+}
+
+/**
+ * Function to check if the byte at offset i from the
+ * current position is a digit
+ */
+func (p *parser) isDigitAt(i int) bool {
+	return p.ensure(i+1) && p.data[p.pos+i] >= '0' && p.data[p.pos+i] <= '9'
+}
+
+/**
+ * Function to check if the byte at offset i from the
+ * current position is one of the bytes in set
+ */
+func (p *parser) isByteAt(i int, set string) bool {
+	return p.ensure(i+1) && strings.IndexByte(set, p.data[p.pos+i]) >= 0
+}
+
+/**
+ * Function to scan the number literal at the current
+ * position. The position isn't moved so the literal
+ * stays in data while reading from a stream. Returns
+ * the length of the literal and if it has a fraction
+ * or exponent
+ */
+func (p *parser) scanNumber() (int, bool, error) {
+	var n int
+	var isDouble bool = false
+
+	if p.isByteAt(n, "-") {
+		n++
+	}
+
+	if p.isDigitAt(n) == false {
+		return 0, false, p.errorAtEnd(p.pos+n, "digit")
+	}
+
+	/*
+	 * A leading zero can't be followed by other digits
+	 */
+	if p.data[p.pos+n] == '0' {
+		n++
+	} else {
+		for p.isDigitAt(n) {
+			n++
+		}
+	}
+
+	if p.isByteAt(n, ".") {
+		n++
 		isDouble = true
 
-		for {
-			if unicode.IsDigit(rune(input[offset])) == true {
-				n = (n * 10.0) + float64(input[offset]-'0')
-				offset++
-				scale--
-			} else {
-				break
-			}
+		if p.isDigitAt(n) == false {
+			return 0, false, p.errorAtEnd(p.pos+n, "digit")
+		}
+
+		for p.isDigitAt(n) {
+			n++
 		}
 	}
 
-	if input[offset] == 'e' || input[offset] == 'E' {
-		offset++
+	if p.isByteAt(n, "eE") {
+		n++
 		isDouble = true
 
-		if input[offset] == '-' {
-			signsubscale = -1
+		if p.isByteAt(n, "+-") {
+			n++
 		}
-		offset++
 
-		for {
-			if unicode.IsDigit(rune(input[offset])) == true {
-				subscale = (subscale * 10) + int(input[offset]-'0')
-				offset++
-			} else {
-				break
-			}
+		if p.isDigitAt(n) == false {
+			return 0, false, p.errorAtEnd(p.pos+n, "digit")
+		}
+
+		for p.isDigitAt(n) {
+			n++
 		}
 	}
 
-	n = sign * n * math.Pow(10.0, scale+float64(subscale)*float64(signsubscale))
+	return n, isDouble, nil
+}
 
-	if isDouble == true {
-		cur.Valdouble = n
-		cur.Jsontype = JSON_DOUBLE
-	} else if sign == -1 {
-		cur.Valint = int64(n)
-		cur.Jsontype = JSON_INT
-	} else {
-		cur.Valuint = uint64(n)
-		cur.Jsontype = JSON_UINT
+/**
+ * Function to convert a number literal to the nearest
+ * double, rounding correctly. Fails for literals whose
+ * magnitude is beyond the range of a double
+ */
+func parseDouble(lit []byte) (float64, error) {
+	return strconv.ParseFloat(string(lit), 64)
+}
+
+/**
+ * Function to parse a number. Integers are parsed exactly
+ * into Valint when negative and Valuint otherwise. Integers
+ * out of the int64/uint64 range are handled according to
+ * the IntOverflow option
+ */
+func (p *parser) parseNumber(cur *GoJSON) error {
+	n, isDouble, err := p.scanNumber()
+
+	if err != nil {
+		return err
+	}
+
+	lit := p.data[p.pos : p.pos+n]
+
+	if p.opts.NumberMode == NUMBER_LITERAL {
+		cur.Valnum = string(lit)
+		cur.Jsontype = JSON_NUMBER
+		p.pos += n
+		return nil
+	}
+
+	if isDouble == false {
+		if lit[0] == '-' {
+			cur.Valint, err = strconv.ParseInt(string(lit), 10, 64)
+			cur.Jsontype = JSON_INT
+		} else {
+			cur.Valuint, err = strconv.ParseUint(string(lit), 10, 64)
+			cur.Jsontype = JSON_UINT
+		}
+
+		if err == nil {
+			p.keepLiteral(cur, lit)
+			p.pos += n
+			return nil
+		}
+
+		cur.Valint, cur.Valuint = 0, 0
+
+		if p.opts.IntOverflow == OVERFLOW_ERROR {
+			return p.errorWrap(p.pos, "integer "+string(lit)+" overflows 64 bits", "", ErrNumberRange)
+		}
+	}
+
+	if cur.Valdouble, err = parseDouble(lit); err != nil {
+		cur.Valdouble = 0
+		return p.errorWrap(p.pos, "number "+string(lit)+" overflows a double", "", ErrNumberRange)
 	}
 
-	return input[offset:], nil
+	cur.Jsontype = JSON_DOUBLE
+	p.keepLiteral(cur, lit)
+	p.pos += n
+
+	return nil
+}
+
+/**
+ * Function to save the literal of a parsed number
+ * when KeepNumberLiteral is set
+ */
+func (p *parser) keepLiteral(cur *GoJSON, lit []byte) {
+	if p.opts.KeepNumberLiteral {
+		cur.Valnum = string(lit)
+	}
+}
+
+/**
+ * Function to enter a nested array or object and
+ * check the nesting depth limit
+ */
+func (p *parser) enter() error {
+	p.depth++
+
+	if p.depth > p.maxDepth {
+		return p.limitError(p.pos, fmt.Sprintf("exceeded max nesting depth of %d", p.maxDepth))
+	}
+
+	return nil
+}
+
+/**
+ * Function to check the member count of an array or object
+ */
+func (p *parser) checkMembers(count int) error {
+	if p.opts.MaxMembers > 0 && count > p.opts.MaxMembers {
+		return p.limitError(p.pos, fmt.Sprintf("exceeded max of %d members", p.opts.MaxMembers))
+	}
+
+	return nil
 }
 
 /**
  * Function to parse an array
  */
-func parseArray(cur *GoJSON, input []byte) ([]byte, error) {
+func (p *parser) parseArray(cur *GoJSON) error {
 	var child, sibling *GoJSON
+	var count int = 1
 
-	if len(input) == 0 {
-		errorStr := fmt.Sprintf("%s: Byte slice is empty", funcName())
-		return []byte{}, errors.New(errorStr)
+	if p.ensure(1) == false || p.data[p.pos] != '[' {
+		return p.unexpected("'['")
 	}
 
-	if input[0] != '[' {
-		errorStr := fmt.Sprintf("%s: Not a valid array in input %s", funcName(), string(input))
-		return []byte{}, errors.New(errorStr)
+	if err := p.enter(); err != nil {
+		return err
 	}
 
 	cur.Jsontype = JSON_ARRAY
-	input = nextToken(input[1:])
+	p.pos++
 
-	if len(input) == 0 {
-		errorStr := fmt.Sprintf("%s: Could not find any valid JSON", funcName())
-		return []byte{}, errors.New(errorStr)
+	if p.nextToken() == false {
+		return p.unexpected("value or ']'")
 	}
 
 	/*
 	 * Check if the array is empty
 	 */
-	if input[0] == ']' {
-		return input[1:], nil
+	if p.data[p.pos] == ']' {
+		p.pos++
+		p.depth--
+		return nil
+	}
+
+	if err := p.checkMembers(count); err != nil {
+		return err
 	}
 
 	/*
@@ -257,260 +766,267 @@ func parseArray(cur *GoJSON, input []byte) ([]byte, error) {
 	 */
 	cur.Child = new(GoJSON)
 	child = cur.Child
-	input, err := parseValue(child, nextToken(input))
 
-	if err != nil {
-		return []byte{}, err
+	if err := p.parseValue(child); err != nil {
+		return err
 	}
 
-	input = nextToken(input)
-
-	if len(input) == 0 {
-		return []byte{}, nil
+	if p.nextToken() == false {
+		return p.unexpected("',' or ']'")
 	}
 
 	/*
 	 * Continue processing the array and add the
 	 * child entries to this parent GoJSON object
 	 */
-	for {
-		if input[0] == ',' {
-			sibling = new(GoJSON)
-			child.Next = sibling
-			sibling.Prev = child
-			child = sibling
+	for p.data[p.pos] == ',' {
+		p.pos++
+		count++
 
-			input, err = parseValue(child, nextToken(input[1:]))
+		if err := p.checkMembers(count); err != nil {
+			return err
+		}
 
-			if err != nil {
-				return []byte{}, err
-			}
+		sibling = new(GoJSON)
+		child.Next = sibling
+		sibling.Prev = child
+		child = sibling
 
-			input = nextToken(input)
+		if err := p.parseValue(child); err != nil {
+			return err
+		}
 
-			if len(input) == 0 {
-				return []byte{}, nil
-			}
-		} else {
-			break
+		if p.nextToken() == false {
+			return p.unexpected("',' or ']'")
 		}
 	}
 
-	if input[0] == ']' {
-		return input[1:], nil
-	} else {
-		errorStr := fmt.Sprintf("%s: Incomplete/Malformed array in input %s", funcName(), string(input))
-		return []byte{}, errors.New(errorStr)
+	if p.data[p.pos] != ']' {
+		return p.unexpected("',' or ']'")
 	}
+
+	p.pos++
+	p.depth--
+	return nil
 }
 
 /**
- * Function to parse an object
+ * Function to parse a key and the following ':' of an object entry
  */
-func parseObject(cur *GoJSON, input []byte) ([]byte, error) {
-	var child, sibling *GoJSON
+func (p *parser) parseKey(cur *GoJSON) error {
+	p.nextToken()
 
-	if len(input) == 0 {
-		errorStr := fmt.Sprintf("%s: Byte slice is empty", funcName())
-		return []byte{}, errors.New(errorStr)
+	if err := p.parseString(cur); err != nil {
+		return err
 	}
 
-	if input[0] != '{' {
-		errorStr := fmt.Sprintf("%s: Not a valid object", funcName())
-		return []byte{}, errors.New(errorStr)
-	}
-
-	cur.Jsontype = JSON_OBJECT
-	input = nextToken(input[1:])
-
-	if len(input) == 0 {
-		errorStr := fmt.Sprintf("%s: Could not find any valid JSON in input %s", funcName(), string(input))
-		return []byte{}, errors.New(errorStr)
-	}
+	cur.Key = cur.Valstr
+	cur.Valstr = ""
 
 	/*
-	 * Check if the object is empty
+	 * Fetch the location of ':' after the object key
 	 */
-	if input[0] == '}' {
-		return input[1:], nil
+	if p.nextToken() == false || p.data[p.pos] != ':' {
+		return p.unexpected("':'")
 	}
 
-	/*
-	 * Allocate memory for the child to
-	 * continue processing
-	 */
-	cur.Child = new(GoJSON)
-	child = cur.Child
-	input, err := parseString(child, nextToken(input))
-
-	if err != nil {
-		return []byte{}, err
-	}
+	p.pos++
+	return nil
+}
 
-	child.Key = child.Valstr
-	child.Valstr = ""
+/**
+ * Function to parse an object
+ */
+func (p *parser) parseObject(cur *GoJSON) error {
+	var child, entry *GoJSON
+	var keys map[string]*GoJSON
+	var count int
 
-	/*
-	 * Fetch the location of ':' after the object key
-	 */
-	input = nextToken(input)
-	if len(input) == 0 {
-		return []byte{}, nil
+	if p.ensure(1) == false || p.data[p.pos] != '{' {
+		return p.unexpected("'{'")
 	}
 
-	if input[0] != ':' {
-		errorStr := fmt.Sprintf("%s: Malformed object in input %s", funcName(), string(input))
-		return []byte{}, errors.New(errorStr)
+	if err := p.enter(); err != nil {
+		return err
 	}
 
-	input, err = parseValue(child, nextToken(input[1:]))
+	cur.Jsontype = JSON_OBJECT
+	p.pos++
 
-	if err != nil {
-		return []byte{}, err
+	if p.nextToken() == false {
+		return p.unexpected("string or '}'")
 	}
 
-	if input[0] != ',' {
-		input = nextToken(input)
+	/*
+	 * Check if the object is empty
+	 */
+	if p.data[p.pos] == '}' {
+		p.pos++
+		p.depth--
+		return nil
+	}
 
-		if len(input) == 0 {
-			return []byte{}, nil
-		}
+	if p.opts.DuplicateKeys != DUPKEY_ALLOW {
+		keys = make(map[string]*GoJSON)
 	}
 
 	/*
-	 * Continue processing the object and add the
+	 * Process the object entries and add them as
 	 * child entries to this parent GoJSON object
 	 */
 	for {
-		if input[0] == ',' {
-			sibling = new(GoJSON)
-			child.Next = sibling
-			sibling.Prev = child
-			child = sibling
+		count++
+		p.nextToken()
 
-			input, err = parseString(child, nextToken(input[1:]))
+		if err := p.checkMembers(count); err != nil {
+			return err
+		}
 
-			if err != nil {
-				return []byte{}, err
-			}
+		keyPos := p.pos
+		entry = new(GoJSON)
 
-			child.Key = child.Valstr
-			child.Valstr = ""
+		if err := p.parseKey(entry); err != nil {
+			return err
+		}
 
-			/*
-			 * Fetch the location of ':' after the object key
-			 */
-			input = nextToken(input)
-			if len(input) == 0 {
-				return []byte{}, nil
-			}
+		if err := p.parseValue(entry); err != nil {
+			return err
+		}
 
-			if input[0] != ':' {
-				errorStr := fmt.Sprintf("%s: Malformed object in input %s", funcName(), string(input))
-				return []byte{}, errors.New(errorStr)
+		/*
+		 * Apply the duplicate key policy. With DUPKEY_LAST
+		 * the value of the earlier entry is replaced so the
+		 * entry keeps its original position
+		 */
+		if prev, found := keys[entry.Key]; found {
+			switch p.opts.DuplicateKeys {
+			case DUPKEY_REJECT:
+				return p.errorWrap(keyPos, "duplicate key "+strconv.Quote(entry.Key), "", ErrDuplicateKey)
+			case DUPKEY_LAST:
+				entry.Next, entry.Prev = prev.Next, prev.Prev
+				*prev = *entry
 			}
-
-			input, err = parseValue(child, nextToken(input[1:]))
-
-			if err != nil {
-				return []byte{}, err
+			count--
+		} else {
+			if keys != nil {
+				keys[entry.Key] = entry
 			}
 
-			if input[0] != ',' {
-				input = nextToken(input)
-
-				if len(input) == 0 {
-					return []byte{}, nil
-				}
+			if child == nil {
+				cur.Child = entry
+			} else {
+				resolveLink(child, entry)
 			}
+			child = entry
+		}
 
-		} else {
+		if p.nextToken() == false {
+			return p.unexpected("',' or '}'")
+		}
+
+		if p.data[p.pos] != ',' {
 			break
 		}
+		p.pos++
 	}
 
-	if input[0] == '}' {
-		return input[1:], nil
-	} else {
-		errorStr := fmt.Sprintf("%s: Incomplete/Malformed object in input %s", funcName(), string(input))
-		return []byte{}, errors.New(errorStr)
+	if p.data[p.pos] != '}' {
+		return p.unexpected("',' or '}'")
 	}
+
+	p.pos++
+	p.depth--
+	return nil
 }
 
 /**
- * Function to parse the current token
+ * Function to check that only whitespace
+ * follows the top level value
  */
-func parseValue(cur *GoJSON, input []byte) ([]byte, error) {
-	input = nextToken(input)
-        length := len(input)
+func (p *parser) checkEnd() error {
+	if p.nextToken() == true {
+		return p.errorAt(p.pos, "invalid character "+
+			quoteChar(p.data[p.pos])+" after top-level value", "end of input")
+	}
 
-	if length == 0 {
-            cur.Jsontype = JSON_NULL
-            return input[:], nil
+	return nil
+}
+
+/**
+ * Function to parse the current token
+ */
+func (p *parser) parseValue(cur *GoJSON) error {
+	if p.nextToken() == false {
+		return p.unexpected("value")
 	}
 
-	if length >= 4 &&
-        strings.Compare(string(input[:4]), "null") == 0 {
+	switch c := p.data[p.pos]; {
+	case c == 'n' && p.hasPrefix("null"):
 		cur.Jsontype = JSON_NULL
-		return input[4:], nil
-	}
+		p.pos += 4
+		return nil
 
-	if length >= 5 && strings.Compare(string(input[:5]), "false") == 0 {
+	case c == 'f' && p.hasPrefix("false"):
 		cur.Jsontype = JSON_BOOL
 		cur.Valbool = false
-		return input[5:], nil
-	}
+		p.pos += 5
+		return nil
 
-	if length >= 4 && strings.Compare(string(input[:4]), "true") == 0 {
+	case c == 't' && p.hasPrefix("true"):
 		cur.Jsontype = JSON_BOOL
 		cur.Valbool = true
-		return input[4:], nil
-	}
+		p.pos += 4
+		return nil
 
-	if input[0] >= '0' && input[0] <= '9' {
-		return parseNumber(cur, input)
-	}
-
-	switch input[0] {
-	case '"':
-		return parseString(cur, input)
-
-	case '-':
-		return parseNumber(cur, input)
+	case c == '"':
+		return p.parseString(cur)
 
-	case '[':
-		return parseArray(cur, input)
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber(cur)
 
-	case '{':
-		return parseObject(cur, input)
+	case c == '[':
+		return p.parseArray(cur)
 
+	case c == '{':
+		return p.parseObject(cur)
 	}
 
-	errorStr := fmt.Sprintf("%s: Parsing Error", funcName())
-	return []byte{}, errors.New(errorStr)
+	return p.unexpected("value")
 }
 
 /*
  * Function to begin processing the string input
- * as a byte sequence
+ * as a byte sequence. The input must be a single
+ * JSON value conforming to RFC 8259, optionally
+ * surrounded by whitespace. Malformed input is
+ * reported with a *SyntaxError
  */
 func GoJSONParse(input []byte) (*GoJSON, error) {
+	return GoJSONParseWithOptions(input, ParseOptions{})
+}
+
+/*
+ * Function to parse the input applying the limits
+ * and policies in opts. Inputs exceeding a limit are
+ * reported with a *SyntaxError matching ErrLimitExceeded
+ */
+func GoJSONParseWithOptions(input []byte, opts ParseOptions) (*GoJSON, error) {
 	var g *GoJSON
 
 	g = new(GoJSON)
+	p := newParser(input, opts)
 
-	input = nextToken(input)
-
-	if len(input) == 0 {
-		errorStr := fmt.Sprintf("%s: Could not find any valid JSON in input %s", funcName(), string(input))
-		return nil, errors.New(errorStr)
+	if opts.MaxInputBytes > 0 && len(input) > opts.MaxInputBytes {
+		return nil, p.limitError(opts.MaxInputBytes,
+			fmt.Sprintf("input exceeds max size of %d bytes", opts.MaxInputBytes))
 	}
 
-	_, err := parseValue(g, input)
+	if err := p.parseValue(g); err != nil {
+		return nil, err
+	}
 
-	if err != nil {
-		errorStr := fmt.Sprintf("%s: JSON Parse failed with error %s ", funcName(), err)
-		return nil, errors.New(errorStr)
+	if err := p.checkEnd(); err != nil {
+		return nil, err
 	}
 
 	return g, nil
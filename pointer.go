@@ -0,0 +1,337 @@
+package jsonez
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/**
+ * JSON Pointer (RFC 6901) addressing of GoJSON objects.
+ * A pointer such as "/outer/val5/2" is a list of reference
+ * tokens, each one an object key or an array index, with
+ * "~" and "/" in keys escaped as "~0" and "~1". The empty
+ * pointer refers to the whole tree
+ */
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+/*
+ * Function to split a JSON pointer into its unescaped
+ * reference tokens
+ */
+func ParsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("jsonez: %w %q: must start with /", ErrInvalidPointer, ptr)
+	}
+
+	tokens := strings.Split(ptr[1:], "/")
+
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("jsonez: %w %q: bad escape in %q", ErrInvalidPointer, ptr, token)
+			}
+		}
+
+		tokens[i] = pointerUnescaper.Replace(token)
+	}
+
+	return tokens, nil
+}
+
+/*
+ * Function to build a JSON pointer from reference tokens
+ */
+func FormatPointer(tokens []string) string {
+	var sb strings.Builder
+
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(pointerEscaper.Replace(token))
+	}
+
+	return sb.String()
+}
+
+/**
+ * Function to convert a reference token to an index into
+ * an array of the given size. "-" refers to the position
+ * past the last element and is accepted only when end is set
+ */
+func pointerIndex(token string, size int, end bool) (int, error) {
+	if token == "-" && end {
+		return size, nil
+	}
+
+	/*
+	 * Indices are decimal without leading zeros
+	 */
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("jsonez: %w: bad array index %q", ErrInvalidPointer, token)
+	}
+
+	index, err := strconv.Atoi(token)
+
+	if err != nil {
+		return 0, fmt.Errorf("jsonez: %w: bad array index %q", ErrInvalidPointer, token)
+	}
+
+	if index > size || (index == size && !end) {
+		return 0, &IndexError{Index: index, Size: size}
+	}
+
+	return index, nil
+}
+
+/**
+ * Function to get the member of an array or object
+ * referred to by a reference token
+ */
+func (g *GoJSON) pointerChild(token string) (*GoJSON, error) {
+	switch g.Jsontype {
+	case JSON_OBJECT:
+		if child := g.GetObjectEntry(token); child != nil {
+			return child, nil
+		}
+
+		return nil, ErrPathNotFound
+
+	case JSON_ARRAY:
+		index, err := pointerIndex(token, g.GetArraySize(), false)
+		if err != nil {
+			return nil, err
+		}
+
+		return g.GetArrayElemByIndex(index)
+	}
+
+	return nil, ErrPathNotFound
+}
+
+/**
+ * Function to walk the first n tokens from g
+ */
+func (g *GoJSON) walkPointer(tokens []string, n int) (*GoJSON, error) {
+	cur := g
+
+	for i, token := range tokens[:n] {
+		child, err := cur.pointerChild(token)
+
+		if err != nil {
+			return nil, pathError(tokens, i+1, err)
+		}
+
+		cur = child
+	}
+
+	return cur, nil
+}
+
+/*
+ * Method to get the GoJSON object a JSON pointer
+ * refers to, such as "/outer/val5/2"
+ */
+func (g *GoJSON) GetPointer(ptr string) (*GoJSON, error) {
+	tokens, err := ParsePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.walkPointer(tokens, len(tokens))
+}
+
+/*
+ * Method to set the value a JSON pointer refers to. val
+ * is a *GoJSON or an int, uint, double, bool or string
+ * value as for AddVal. A *GoJSON is copied, so it can be
+ * a node of the same tree. An existing value is replaced in
+ * place. Otherwise the parent must exist: a new key is
+ * added to an object, and an array is appended to with
+ * the index "-" or the index equal to its size
+ */
+func (g *GoJSON) SetPointer(ptr string, val interface{}) error {
+	var entry *GoJSON
+
+	tokens, err := ParsePointer(ptr)
+	if err != nil {
+		return err
+	}
+
+	if v, ok := val.(*GoJSON); ok {
+		entry = copyValue(v)
+	} else if entry, err = allocValue(val); err != nil {
+		return err
+	}
+
+	if len(tokens) == 0 {
+		replaceValue(g, entry)
+		return nil
+	}
+
+	parent, err := g.walkPointer(tokens, len(tokens)-1)
+	if err != nil {
+		return err
+	}
+
+	last := tokens[len(tokens)-1]
+
+	switch parent.Jsontype {
+	case JSON_OBJECT:
+		if child := parent.GetObjectEntry(last); child != nil {
+			replaceValue(child, entry)
+		} else {
+			parent.AddEntryToObject(last, entry)
+		}
+
+	case JSON_ARRAY:
+		size := parent.GetArraySize()
+
+		index, err := pointerIndex(last, size, true)
+		if err != nil {
+			return pathError(tokens, len(tokens), err)
+		}
+
+		if index == size {
+			parent.AddEntryToArray(entry)
+		} else {
+			child, _ := parent.GetArrayElemByIndex(index)
+			replaceValue(child, entry)
+		}
+
+	default:
+		return pathError(tokens, len(tokens), ErrPathNotFound)
+	}
+
+	return nil
+}
+
+/*
+ * Method to delete the value a JSON pointer refers to
+ * from its parent array or object
+ */
+func (g *GoJSON) DeletePointer(ptr string) error {
+	tokens, err := ParsePointer(ptr)
+	if err != nil {
+		return err
+	}
+
+	if len(tokens) == 0 {
+		return fmt.Errorf("jsonez: %w: the whole tree can't be deleted", ErrInvalidPointer)
+	}
+
+	parent, err := g.walkPointer(tokens, len(tokens)-1)
+	if err != nil {
+		return err
+	}
+
+	last := tokens[len(tokens)-1]
+
+	switch parent.Jsontype {
+	case JSON_OBJECT:
+		if parent.GetObjectEntry(last) == nil {
+			return pathError(tokens, len(tokens), ErrPathNotFound)
+		}
+
+		return parent.DelEntryFromObject(last)
+
+	case JSON_ARRAY:
+		index, err := pointerIndex(last, parent.GetArraySize(), false)
+		if err != nil {
+			return pathError(tokens, len(tokens), err)
+		}
+
+		return parent.DelIndexFromArray(index)
+	}
+
+	return pathError(tokens, len(tokens), ErrPathNotFound)
+}
+
+/*
+ * Method to compute the JSON pointer of node within
+ * the tree rooted at g
+ */
+func (g *GoJSON) PointerTo(node *GoJSON) (string, error) {
+	if tokens, ok := g.findNode(node, nil); ok {
+		return FormatPointer(tokens), nil
+	}
+
+	return "", fmt.Errorf("jsonez: %w: node isn't part of the tree", ErrPathNotFound)
+}
+
+/**
+ * Function to search the tree rooted at g for node,
+ * returning the reference tokens leading to it
+ */
+func (g *GoJSON) findNode(node *GoJSON, tokens []string) ([]string, bool) {
+	if g == node {
+		return tokens, true
+	}
+
+	if g.Jsontype != JSON_ARRAY && g.Jsontype != JSON_OBJECT {
+		return nil, false
+	}
+
+	i := 0
+
+	for child := g.Child; child != nil; child = child.Next {
+		token := child.Key
+
+		if g.Jsontype == JSON_ARRAY {
+			token = strconv.Itoa(i)
+		}
+
+		if found, ok := child.findNode(node, append(tokens, token)); ok {
+			return found, true
+		}
+
+		i++
+	}
+
+	return nil, false
+}
+
+/**
+ * Function to make a deep copy of the value of src,
+ * not linked to any array or object
+ */
+func copyValue(src *GoJSON) *GoJSON {
+	var last *GoJSON
+
+	dst := new(GoJSON)
+	*dst = *src
+	dst.Key, dst.Next, dst.Prev, dst.Child = "", nil, nil, nil
+
+	for child := src.Child; child != nil; child = child.Next {
+		entry := copyValue(child)
+		entry.Key = child.Key
+
+		if last == nil {
+			dst.Child = entry
+		} else {
+			resolveLink(last, entry)
+		}
+
+		last = entry
+	}
+
+	return dst
+}
+
+/**
+ * Function to replace the value of dst with the value
+ * of src, keeping the key and position of dst
+ */
+func replaceValue(dst, src *GoJSON) {
+	key, next, prev := dst.Key, dst.Next, dst.Prev
+
+	*dst = *src
+	dst.Key, dst.Next, dst.Prev = key, next, prev
+}
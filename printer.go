@@ -1,201 +1,1049 @@
 package jsonez
 
-import "strconv"
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
 
 /**
  * Functions to print the contents
  */
 
+/*
+ * Options to control the printer output
+ */
+type PrintOptions struct {
+	/**
+	 * Escape the HTML sensitive characters <, > and &
+	 * (and U+2028/U+2029) so the output can be safely
+	 * embedded in HTML script tags
+	 */
+	EscapeHTML bool
+
+	/**
+	 * Escape all non-ASCII characters using \uXXXX
+	 * sequences so the output is pure ASCII
+	 */
+	EscapeNonASCII bool
+
+	/**
+	 * Format of doubles without a number literal,
+	 * one of the FLOAT_* values
+	 */
+	FloatFormat int
+
+	/**
+	 * How NaN and infinite doubles are printed, one of
+	 * the NONFINITE_* values. JSON has no representation
+	 * for them, so by default printing fails
+	 */
+	NonFinite int
+
+	/**
+	 * Print without any whitespace between tokens,
+	 * for wire payloads and logs
+	 */
+	Compact bool
+
+	/** String used for each indentation level, "" for a tab */
+	Indent string
+
+	/** String written at the start of every output line */
+	Prefix string
+
+	/**
+	 * String between an object key and its value,
+	 * "" for ": ". Compact output always uses ":"
+	 */
+	KeySeparator string
+
+	/** End the output with a newline */
+	TrailingNewline bool
+
+	/** Layout of empty arrays and objects, one of the EMPTY_* values */
+	EmptyContainers int
+
+	/** Print object keys sorted by their bytes */
+	SortKeys bool
+
+	/**
+	 * Comparator for object keys, used instead of
+	 * SortKeys when set
+	 */
+	KeyLess func(a, b string) bool
+
+	/**
+	 * Keys printed first in the given order, such as
+	 * "id" and "name". The other keys follow, ordered
+	 * by KeyLess or SortKeys if set
+	 */
+	KeyOrder []string
+
+	/** When to color the output, one of the COLOR_* values */
+	ColorMode int
+
+	/** Colors of the tokens, nil for DefaultColors */
+	Colors *Colors
+
+	/**
+	 * Limits for logging large trees, 0 for no limit.
+	 * Arrays and objects nested deeper than MaxDepth,
+	 * string values longer than MaxStringLen bytes and
+	 * members past the first MaxMembers of an array or
+	 * object are elided and replaced with a marker such
+	 * as "…(+998 items)". The output stays valid JSON
+	 */
+	MaxDepth     int
+	MaxStringLen int
+	MaxMembers   int
+
+	/**
+	 * Target line width in bytes for indented output.
+	 * When set, arrays and objects that fit in the
+	 * rest of the line are printed on it, as in
+	 * [1, 2, 3]. 0 puts every member on its own line
+	 */
+	Width int
+}
+
+/*
+ * Layouts of empty arrays and objects in indented output
+ */
+const (
+	/** Print [] and {} */
+	EMPTY_INLINE = iota
+
+	/** Print [ ] and { } */
+	EMPTY_SPACED
+
+	/** Print the closing bracket on its own line */
+	EMPTY_EXPANDED
+)
+
+/*
+ * Formats for printing doubles
+ */
+const (
+	/**
+	 * Shortest representation that parses back to the
+	 * same double, in plain decimal notation unless the
+	 * magnitude is below 1e-6 or at least 1e21, such as
+	 * 225.1245 or 1e+21. This matches JavaScript and
+	 * encoding/json
+	 */
+	FLOAT_ES6 = iota
+
+	/**
+	 * Shortest representation using the %g rules of
+	 * strconv, such as 1.5 or 1e+06
+	 */
+	FLOAT_SHORTEST
+
+	/** Exponent notation such as 1.5E+00 */
+	FLOAT_EXPONENT
+)
+
+/*
+ * Ways of printing NaN and infinite doubles
+ */
+const (
+	/**
+	 * Fail with ErrNumberRange. Encode and NDJSONWriter
+	 * return the error, the functions returning bytes
+	 * can't fail and print null instead
+	 */
+	NONFINITE_ERROR = iota
+
+	/** Print null, as JavaScript does */
+	NONFINITE_NULL
+
+	/** Print the strings "NaN", "Infinity" and "-Infinity" */
+	NONFINITE_STRING
+
+	/**
+	 * Print the bare tokens NaN, Infinity and -Infinity.
+	 * The output is not valid JSON
+	 */
+	NONFINITE_LITERAL
+)
+
+/*
+ * Layouts of arrays and objects, passed down while
+ * printing the tree
+ */
+const (
+	/** No whitespace */
+	layoutCompact = iota
+
+	/** One member per line */
+	layoutIndent
+
+	/** All members on one line, separated by ", " */
+	layoutInline
+)
+
+const hexDigits = "0123456789abcdef"
+
+/**
+ * Function to append a \uXXXX escape for r
+ */
+func appendUnicodeEscape(output []byte, r rune) []byte {
+	return append(output, '\\', 'u',
+		hexDigits[(r>>12)&0xf], hexDigits[(r>>8)&0xf],
+		hexDigits[(r>>4)&0xf], hexDigits[r&0xf])
+}
+
+/**
+ * Function to print a string as a quoted and escaped JSON string
+ */
+func printString(output []byte, str string, opts *PrintOptions) []byte {
+	var start int
+
+	output = append(output, '"')
+
+	for i := 0; i < len(str); {
+		c := str[i]
+
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' &&
+				(!opts.EscapeHTML || (c != '<' && c != '>' && c != '&')) {
+				i++
+				continue
+			}
+
+			output = append(output, str[start:i]...)
+
+			switch c {
+			case '"', '\\':
+				output = append(output, '\\', c)
+			case '\b':
+				output = append(output, '\\', 'b')
+			case '\f':
+				output = append(output, '\\', 'f')
+			case '\n':
+				output = append(output, '\\', 'n')
+			case '\r':
+				output = append(output, '\\', 'r')
+			case '\t':
+				output = append(output, '\\', 't')
+			default:
+				output = appendUnicodeEscape(output, rune(c))
+			}
+
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(str[i:])
+
+		/*
+		 * Invalid UTF-8 is replaced with U+FFFD so that the
+		 * output is always valid JSON
+		 */
+		if r == utf8.RuneError && size == 1 {
+			output = append(output, str[start:i]...)
+			if opts.EscapeNonASCII {
+				output = appendUnicodeEscape(output, utf8.RuneError)
+			} else {
+				output = append(output, "\uFFFD"...)
+			}
+			i += size
+			start = i
+			continue
+		}
+
+		if opts.EscapeNonASCII ||
+			(opts.EscapeHTML && (r == '\u2028' || r == '\u2029')) {
+			output = append(output, str[start:i]...)
+
+			if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+				output = appendUnicodeEscape(output, r1)
+				output = appendUnicodeEscape(output, r2)
+			} else {
+				output = appendUnicodeEscape(output, r)
+			}
+
+			i += size
+			start = i
+			continue
+		}
+
+		i += size
+	}
+
+	output = append(output, str[start:]...)
+	output = append(output, '"')
+
+	return output
+}
+
+/**
+ * Size of the output kept in memory before it is
+ * written out when printing to a stream
+ */
+const printChunkSize = 4096
+
+/*
+ * Printer state while walking the tree. The output is
+ * appended to buf, which is written to w and reset as
+ * it fills up when printing to a stream
+ */
+type printer struct {
+	buf  []byte
+	opts *PrintOptions
+
+	/** Stream the output is written to, nil for a slice */
+	w io.Writer
+
+	/** Print the canonical form of RFC 8785 */
+	canonical bool
+
+	/** Colors of the tokens, nil for uncolored output */
+	colors *Colors
+
+	/**
+	 * Offset in buf where the current line starts and
+	 * the number of color bytes printed on it, to track
+	 * the column. The offset goes negative when the
+	 * start of the line has been written out
+	 */
+	lineStart, lineColor int
+
+	/**
+	 * Number of bytes following the value being printed
+	 * on the same line, 1 for the "," after a member
+	 */
+	trailing int
+
+	/** First error returned by w or the canonical checks */
+	err error
+}
+
+/**
+ * Function to record the first printing error
+ */
+func (p *printer) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+/**
+ * Function to write out the output printed so far
+ */
+func (p *printer) flush() {
+	if p.w == nil || len(p.buf) == 0 {
+		return
+	}
+
+	if p.err == nil {
+		_, p.err = p.w.Write(p.buf)
+	}
+
+	p.lineStart -= len(p.buf)
+	p.buf = p.buf[:0]
+}
+
 /**
- * Function to print a number
+ * Function to print a number. A number literal kept
+ * from the input is printed as is
  */
-func printNumber(cur *GoJSON) []byte {
+func (p *printer) printNumber(cur *GoJSON) {
+	if p.canonical {
+		p.printCanonicalNumber(cur)
+		return
+	}
+
+	if cur.Valnum != "" {
+		p.buf = append(p.buf, cur.Valnum...)
+		return
+	}
 
 	switch cur.Jsontype {
 	case JSON_DOUBLE:
-		return []byte(strconv.FormatFloat(cur.Valdouble, 'E', -1, 64))
+		f := cur.Valdouble
+
+		if p.opts.NonFinite == NONFINITE_ERROR && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			p.fail(fmt.Errorf("jsonez: %w: %v can't be represented in JSON", ErrNumberRange, f))
+		}
+
+		p.buf = printDouble(p.buf, f, p.opts)
 
 	case JSON_INT:
-		return []byte(strconv.FormatInt(int64(cur.Valint), 10))
+		p.buf = strconv.AppendInt(p.buf, cur.Valint, 10)
 
 	case JSON_UINT:
-		return []byte(strconv.FormatUint(cur.Valuint, 10))
+		p.buf = strconv.AppendUint(p.buf, cur.Valuint, 10)
+	}
+}
+
+/**
+ * Function to print a double
+ */
+func printDouble(output []byte, f float64, opts *PrintOptions) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		var name string
+
+		switch {
+		case math.IsNaN(f):
+			name = "NaN"
+		case f > 0:
+			name = "Infinity"
+		default:
+			name = "-Infinity"
+		}
+
+		switch opts.NonFinite {
+		case NONFINITE_STRING:
+			return append(append(append(output, '"'), name...), '"')
+		case NONFINITE_LITERAL:
+			return append(output, name...)
+		}
+
+		return append(output, "null"...)
+	}
+
+	switch opts.FloatFormat {
+	case FLOAT_SHORTEST:
+		return strconv.AppendFloat(output, f, 'g', -1, 64)
+	case FLOAT_EXPONENT:
+		return strconv.AppendFloat(output, f, 'E', -1, 64)
+	}
+
+	/*
+	 * Negative zero is printed as 0, as in JavaScript
+	 */
+	if f == 0 {
+		f = 0
+	}
+
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		output = strconv.AppendFloat(output, f, 'e', -1, 64)
+
+		/*
+		 * Drop the leading zero of a single digit
+		 * exponent, e-07 becomes e-7
+		 */
+		if n := len(output); n >= 4 && output[n-4] == 'e' && output[n-2] == '0' {
+			output[n-2] = output[n-1]
+			output = output[:n-1]
+		}
+
+		return output
 	}
 
-	return []byte{}
+	return strconv.AppendFloat(output, f, 'f', -1, 64)
 }
 
 /**
- * Function to print an array
+ * Function to start a new line indented to depth
+ */
+func (p *printer) printNewline(depth int) {
+	indent := p.opts.Indent
+
+	if indent == "" {
+		indent = "\t"
+	}
+
+	p.buf = append(p.buf, '\n')
+	p.lineStart, p.lineColor = len(p.buf), 0
+	p.buf = append(p.buf, p.opts.Prefix...)
+
+	for j := 0; j < depth; j++ {
+		p.buf = append(p.buf, indent...)
+	}
+}
+
+/**
+ * Function to get the separator between an object
+ * key and its value
+ */
+func (p *printer) keySeparator(fmt int) string {
+	switch {
+	case fmt == layoutCompact:
+		return ":"
+	case p.opts.KeySeparator == "":
+		return ": "
+	}
+
+	return p.opts.KeySeparator
+}
+
+/**
+ * Function to print the "," after a member. Inline
+ * containers add a space after it
+ */
+func (p *printer) printSeparator(fmt int) {
+	if fmt == layoutInline {
+		p.buf = append(p.buf, ',', ' ')
+	} else {
+		p.buf = append(p.buf, ',')
+	}
+}
+
+/**
+ * Function to get the number of bytes printed after
+ * a member on the same line
+ */
+func (p *printer) separatorLen(last bool) int {
+	if last {
+		return 0
+	}
+
+	return 1
+}
+
+/**
+ * Function to get the current column, in bytes
+ */
+func (p *printer) column() int {
+	return len(p.buf) - p.lineStart - p.lineColor
+}
+
+/**
+ * Function to count the members from child to the end
+ */
+func countMembers(child *GoJSON) int {
+	var n int
+
+	for ; child != nil; child = child.Next {
+		n++
+	}
+
+	return n
+}
+
+/**
+ * Function to check if the members of an array or
+ * object starting from the i-th one are elided
+ */
+func (p *printer) elideMembers(i int) bool {
+	return p.opts.MaxMembers > 0 && i >= p.opts.MaxMembers
+}
+
+/**
+ * Function to check if a non empty array or object
+ * at depth is elided
+ */
+func (p *printer) elideDepth(cur *GoJSON, depth int) bool {
+	return p.opts.MaxDepth > 0 && depth > p.opts.MaxDepth && cur.Child != nil
+}
+
+/**
+ * Function to get the marker for n elided items,
+ * keys or bytes such as "…(+998 items)"
+ */
+func elidedMarker(n int, unit string) string {
+	return "\u2026(+" + strconv.Itoa(n) + " " + unit + ")"
+}
+
+/**
+ * Function to print a marker for n elided items or keys
+ */
+func (p *printer) printElided(n int, unit string) {
+	p.buf = printString(p.buf, elidedMarker(n, unit), p.opts)
+}
+
+/**
+ * Function to print a marker for n elided object
+ * members, as a member with a null value
+ */
+func (p *printer) printElidedMembers(n, depth, fmt int) {
+	if fmt == layoutIndent {
+		p.printNewline(depth)
+	}
+
+	p.printElided(n, "keys")
+	p.buf = append(p.buf, p.keySeparator(fmt)...)
+	p.buf = append(p.buf, "null"...)
+}
+
+/**
+ * Function to cut a string value to MaxStringLen bytes,
+ * on a character boundary, followed by a marker for the
+ * elided bytes
+ */
+func (p *printer) truncateString(str string) string {
+	n := p.opts.MaxStringLen
+
+	if n <= 0 || len(str) <= n {
+		return str
+	}
+
+	for n > 0 && !utf8.RuneStart(str[n]) {
+		n--
+	}
+
+	return str[:n] + elidedMarker(len(str)-n, "bytes")
+}
+
+/**
+ * Function to get the width of a string printed inline.
+ * Strings that can't fit in limit aren't escaped, as
+ * escaping only makes them longer
+ */
+func (p *printer) stringWidth(str string, limit int) int {
+	if len(str)+2 > limit {
+		return len(str) + 2
+	}
+
+	return len(printString(p.buf, str, p.opts)) - len(p.buf)
+}
+
+/**
+ * Function to get the width of a value printed inline,
+ * with the MaxDepth, MaxStringLen and MaxMembers limits
+ * applied. depth is the depth of cur if it is an array
+ * or object. Counting stops once the width is past limit
  */
-func printArray(cur *GoJSON, depth, fmt int) []byte {
-	var entryCount int = 0
-	var output []byte
-	var child *GoJSON
+func (p *printer) flatWidth(cur *GoJSON, depth, limit int) int {
+	switch cur.Jsontype {
+	case JSON_NULL:
+		return len("null")
+
+	case JSON_BOOL:
+		if cur.Valbool == false {
+			return len("false")
+		}
+		return len("true")
+
+	case JSON_STRING:
+		if p.opts.MaxStringLen > 0 && len(cur.Valstr) > p.opts.MaxStringLen {
+			return p.stringWidth(p.truncateString(cur.Valstr), limit)
+		}
+
+		return p.stringWidth(cur.Valstr, limit)
+
+	case JSON_ARRAY, JSON_OBJECT:
+		unit := "items"
+
+		if cur.Jsontype == JSON_OBJECT {
+			unit = "keys"
+		}
+
+		if p.elideDepth(cur, depth) {
+			return p.stringWidth(elidedMarker(countMembers(cur.Child), unit), limit)
+		}
+
+		if cur.Child == nil && p.opts.EmptyContainers == EMPTY_SPACED {
+			return 3
+		}
+
+		/*
+		 * Members are measured in the order they are
+		 * printed, so MaxMembers keeps the same ones
+		 */
+		var members []*GoJSON
+
+		if cur.Jsontype == JSON_OBJECT {
+			members = p.sortMembers(cur)
+		}
+
+		width := 2
+		child := cur.Child
+
+		if members != nil {
+			child = members[0]
+		}
+
+		for i := 0; child != nil && width <= limit; i++ {
+			if p.elideMembers(i) {
+				n := countMembers(child)
+
+				if members != nil {
+					n = len(members) - i
+				}
+
+				width += p.stringWidth(elidedMarker(n, unit), limit-width)
+
+				if cur.Jsontype == JSON_OBJECT {
+					width += len(p.keySeparator(layoutInline)) + len("null")
+				}
+
+				break
+			}
+
+			if cur.Jsontype == JSON_OBJECT {
+				width += p.stringWidth(child.Key, limit-width)
+				width += len(p.keySeparator(layoutInline))
+			}
+
+			width += p.flatWidth(child, depth+1, limit-width)
+
+			if members != nil {
+				child = nil
+
+				if i+1 < len(members) {
+					child = members[i+1]
+				}
+			} else {
+				child = child.Next
+			}
+
+			if child != nil {
+				width += 2
+			}
+		}
+
+		return width
+	}
 
 	/*
-	 * Get the child count
+	 * Numbers are printed past the end of the output
+	 * and dropped
 	 */
-	for child = cur.Child; child != nil; child = child.Next {
-		entryCount++
+	buf := p.buf
+	p.printNumber(cur)
+	width := len(p.buf) - len(buf)
+	p.buf = buf
+
+	return width
+}
+
+/**
+ * Function to choose the layout of a non empty array
+ * or object. With a Width set, an indented container
+ * that fits in the rest of the line is printed inline
+ */
+func (p *printer) layout(cur *GoJSON, depth, fmt int) int {
+	if fmt != layoutIndent || p.opts.Width <= 0 {
+		return fmt
+	}
+
+	avail := p.opts.Width - p.column() - p.trailing
+
+	if p.flatWidth(cur, depth, avail) <= avail {
+		return layoutInline
 	}
 
-	if entryCount == 0 {
-		return []byte("[]")
+	return layoutIndent
+}
+
+/**
+ * Function to print an empty array or object
+ */
+func (p *printer) printEmpty(open, close byte, depth, fmt int) {
+	p.buf = append(p.buf, open)
+
+	switch {
+	case fmt == layoutCompact:
+	case p.opts.EmptyContainers == EMPTY_SPACED:
+		p.buf = append(p.buf, ' ')
+	case p.opts.EmptyContainers == EMPTY_EXPANDED && fmt == layoutIndent:
+		p.printNewline(depth - 1)
+	}
+
+	p.buf = append(p.buf, close)
+}
+
+/**
+ * Function to print an array
+ */
+func (p *printer) printArray(cur *GoJSON, depth, fmt int) {
+	if cur.Child == nil {
+		p.printEmpty('[', ']', depth, fmt)
+		return
 	}
 
 	/*
 	 * Print the child entries
 	 */
-	child = cur.Child
-	output = append(output, '[', '\n')
+	fmt = p.layout(cur, depth, fmt)
+	p.buf = append(p.buf, '[')
+	i := 0
 
-	for i := 0; i < entryCount; i++ {
-		if fmt != 0 {
-			for j := 0; j < depth; j++ {
-				output = append(output, '\t')
-			}
+	for child := cur.Child; child != nil; child = child.Next {
+		if fmt == layoutIndent {
+			p.printNewline(depth)
 		}
 
-		output = append(output, printValue(child, depth, fmt)...)
+		if p.elideMembers(i) {
+			p.printElided(countMembers(child), "items")
+			break
+		}
+
+		i++
+		p.trailing = p.separatorLen(child.Next == nil)
+		p.printValue(child, depth, fmt)
 
 		/*
 		 * Add a "," if this not the last entry
 		 */
-		if i != entryCount-1 {
-			output = append(output, ',')
+		if child.Next != nil {
+			p.printSeparator(fmt)
 		}
 
-		output = append(output, '\n')
+		if len(p.buf) >= printChunkSize {
+			p.flush()
+		}
+	}
 
-		child = child.Next
+	if fmt == layoutIndent {
+		p.printNewline(depth - 1)
 	}
 
-	if fmt != 0 {
-		for j := 0; j < depth-1; j++ {
-			output = append(output, '\t')
+	p.buf = append(p.buf, ']')
+}
+
+/**
+ * Function to get the position of a key in KeyOrder,
+ * len(KeyOrder) for keys that aren't listed
+ */
+func (p *printer) keyRank(key string) int {
+	for i, k := range p.opts.KeyOrder {
+		if k == key {
+			return i
 		}
 	}
 
-	output = append(output, ']')
+	return len(p.opts.KeyOrder)
+}
+
+/**
+ * Function to compare two keys according to the
+ * KeyOrder, KeyLess and SortKeys options
+ */
+func (p *printer) keyLess(a, b string) bool {
+	if ra, rb := p.keyRank(a), p.keyRank(b); ra != rb {
+		return ra < rb
+	}
 
-	return output
+	if p.opts.KeyLess != nil {
+		return p.opts.KeyLess(a, b)
+	}
+
+	if p.opts.SortKeys {
+		return a < b
+	}
+
+	return false
 }
 
 /**
- * Function to print an object
+ * Function to get the members of an object in the
+ * order they are to be printed. Returns nil when
+ * they are printed in insertion order
  */
-func printObject(cur *GoJSON, depth, fmt int) []byte {
-	var entryCount int = 0
-	var output []byte
-	var child *GoJSON
+func (p *printer) sortMembers(cur *GoJSON) []*GoJSON {
+	var members []*GoJSON
 
-	/*
-	 * Get the child count
-	 */
-	for child = cur.Child; child != nil; child = child.Next {
-		entryCount++
+	if !p.opts.SortKeys && p.opts.KeyLess == nil && len(p.opts.KeyOrder) == 0 {
+		return nil
 	}
 
-	output = append(output, '{')
-	output = append(output, '\n')
+	for child := cur.Child; child != nil; child = child.Next {
+		members = append(members, child)
+	}
 
-	if entryCount != 0 {
-		/*
-		 * Walk the child entries
-		 */
-		child = cur.Child
+	sort.SliceStable(members, func(i, j int) bool {
+		return p.keyLess(members[i].Key, members[j].Key)
+	})
 
-		for i := 0; i < entryCount && child != nil; i++ {
-			if fmt != 0 {
-				for j := 0; j < depth; j++ {
-					output = append(output, '\t')
-				}
-			}
+	return members
+}
 
-			output = append(output, '"')
-			output = append(output, []byte(child.Key)...)
-			output = append(output, '"')
+/**
+ * Function to print an object member
+ */
+func (p *printer) printMember(child *GoJSON, depth, fmt int, last bool) {
+	if fmt == layoutIndent {
+		p.printNewline(depth)
+	}
 
-			output = append(output, ':')
+	if p.colors != nil {
+		p.startColor(p.colors.Key)
+		p.buf = printString(p.buf, child.Key, p.opts)
+		p.endColor(p.colors.Key)
+	} else {
+		p.buf = printString(p.buf, child.Key, p.opts)
+	}
 
-			if fmt != 0 {
-				output = append(output, ' ')
-			}
+	p.buf = append(p.buf, p.keySeparator(fmt)...)
 
-			output = append(output, printValue(child, depth, fmt)...)
+	p.trailing = p.separatorLen(last)
+	p.printValue(child, depth, fmt)
 
-			if i != entryCount-1 {
-				output = append(output, ',')
-			}
+	if !last {
+		p.printSeparator(fmt)
+	}
+
+	if len(p.buf) >= printChunkSize {
+		p.flush()
+	}
+}
+
+/**
+ * Function to print an object
+ */
+func (p *printer) printObject(cur *GoJSON, depth, fmt int) {
+	if p.canonical {
+		p.printCanonicalObject(cur, depth)
+		return
+	}
+
+	if cur.Child == nil {
+		p.printEmpty('{', '}', depth, fmt)
+		return
+	}
+
+	/*
+	 * Walk the child entries
+	 */
+	fmt = p.layout(cur, depth, fmt)
+	p.buf = append(p.buf, '{')
 
-			if fmt != 0 {
-				output = append(output, '\n')
+	if members := p.sortMembers(cur); members != nil {
+		for i, child := range members {
+			if p.elideMembers(i) {
+				p.printElidedMembers(len(members)-i, depth, fmt)
+				break
 			}
 
-			child = child.Next
+			p.printMember(child, depth, fmt, i == len(members)-1)
 		}
-	}
+	} else {
+		i := 0
 
-	if fmt != 0 {
-		for j := 0; j < depth-1; j++ {
-			output = append(output, '\t')
+		for child := cur.Child; child != nil; child = child.Next {
+			if p.elideMembers(i) {
+				p.printElidedMembers(countMembers(child), depth, fmt)
+				break
+			}
+
+			p.printMember(child, depth, fmt, child.Next == nil)
+			i++
 		}
 	}
 
-	output = append(output, '}')
+	if fmt == layoutIndent {
+		p.printNewline(depth - 1)
+	}
 
-	return output
+	p.buf = append(p.buf, '}')
 }
 
 /**
  * Function to print the current item
  */
-func printValue(cur *GoJSON, depth, fmt int) []byte {
-	var output []byte
+func (p *printer) printValue(cur *GoJSON, depth, fmt int) {
+	code := p.valueColor(cur)
+	p.startColor(code)
 
 	switch cur.Jsontype {
 	case JSON_NULL:
-		output = append(output, []byte("null")...)
-		return output
+		p.buf = append(p.buf, "null"...)
 
 	case JSON_BOOL:
 		if cur.Valbool == false {
-			output = append(output, []byte("false")...)
+			p.buf = append(p.buf, "false"...)
 		} else {
-			output = append(output, []byte("true")...)
+			p.buf = append(p.buf, "true"...)
 		}
-		return output
 
-	case JSON_INT:
-		fallthrough
-	case JSON_UINT:
-		fallthrough
-	case JSON_DOUBLE:
-		output = append(output, printNumber(cur)...)
-		return output
+	case JSON_INT, JSON_UINT, JSON_DOUBLE, JSON_NUMBER:
+		p.printNumber(cur)
 
 	case JSON_STRING:
-		output = append(output, '"')
-		output = append(output, []byte(cur.Valstr)...)
-		output = append(output, '"')
-		return output
+		if p.canonical {
+			p.checkString(cur.Valstr)
+		}
+
+		p.buf = printString(p.buf, p.truncateString(cur.Valstr), p.opts)
 
 	case JSON_ARRAY:
-		output = append(output, printArray(cur, depth+1, fmt)...)
-		return output
+		if p.elideDepth(cur, depth+1) {
+			p.printElided(countMembers(cur.Child), "items")
+		} else {
+			p.printArray(cur, depth+1, fmt)
+		}
 
 	case JSON_OBJECT:
-		output = append(output, printObject(cur, depth+1, fmt)...)
-		return output
+		if p.elideDepth(cur, depth+1) {
+			p.printElided(countMembers(cur.Child), "keys")
+		} else {
+			p.printObject(cur, depth+1, fmt)
+		}
+	}
+
+	p.endColor(code)
+}
+
+/**
+ * Function to print a whole document from root,
+ * applying the compact, prefix and trailing newline
+ * options
+ */
+func (p *printer) printRoot(root *GoJSON) {
+	p.lineStart = len(p.buf)
+
+	if p.opts.Compact {
+		p.printValue(root, 0, layoutCompact)
+	} else {
+		p.buf = append(p.buf, p.opts.Prefix...)
+		p.printValue(root, 0, layoutIndent)
 	}
 
-	return []byte{}
+	if p.opts.TrailingNewline {
+		p.buf = append(p.buf, '\n')
+	}
 }
 
 /**
  * Main function to print the GoJSON tree from root
  */
 func GoJSONPrint(root *GoJSON) []byte {
-	return printValue(root, 0, 1)
+	return root.AppendJSON(nil)
+}
+
+/**
+ * Function to print the GoJSON tree from root using
+ * the given printer options
+ */
+func GoJSONPrintWithOptions(root *GoJSON, opts PrintOptions) []byte {
+	return root.AppendJSONWithOptions(nil, opts)
+}
+
+/**
+ * Function to print the GoJSON tree from root without
+ * any whitespace
+ */
+func GoJSONPrintCompact(root *GoJSON) []byte {
+	return root.AppendJSONWithOptions(nil, PrintOptions{Compact: true})
+}
+
+/**
+ * Method to append the output of GoJSONPrint for g
+ * to dst, returning the extended slice
+ */
+func (g *GoJSON) AppendJSON(dst []byte) []byte {
+	return g.AppendJSONWithOptions(dst, PrintOptions{})
+}
+
+/**
+ * Method to append the output of GoJSONPrintWithOptions
+ * for g to dst, returning the extended slice
+ */
+func (g *GoJSON) AppendJSONWithOptions(dst []byte, opts PrintOptions) []byte {
+	output, _ := g.appendJSON(dst, &opts)
+
+	return output
+}
+
+/**
+ * Function to append the output for g to dst, also
+ * returning the first printing error. The output is
+ * complete even on error, with null in place of the
+ * values that couldn't be printed
+ */
+func (g *GoJSON) appendJSON(dst []byte, opts *PrintOptions) ([]byte, error) {
+	p := printer{buf: dst, opts: opts, colors: colorsFor(nil, opts)}
+	p.printRoot(g)
+
+	return p.buf, p.err
 }
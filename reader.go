@@ -0,0 +1,241 @@
+package jsonez
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+/**
+ * Functions to parse JSON directly from an io.Reader
+ */
+
+/**
+ * Size of the buffer used to read from a stream
+ */
+const readBufferSize = 4096
+
+/**
+ * Function to create a parser reading from r
+ */
+func newReaderParser(r io.Reader, opts ParseOptions) *parser {
+	p := newParser(nil, opts)
+
+	if rd, ok := r.(*bufio.Reader); ok {
+		p.rd = rd
+	} else {
+		p.rd = bufio.NewReaderSize(r, readBufferSize)
+	}
+
+	return p
+}
+
+/*
+ * Reader returning one byte per call to Read, so
+ * that nothing is read past the end of a value
+ */
+type byteReader struct {
+	r io.ByteReader
+}
+
+func (b byteReader) Read(buf []byte) (int, error) {
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
+	c, err := b.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	buf[0] = c
+	return 1, nil
+}
+
+/**
+ * Function to create a parser reading a single value
+ * from r, that leaves r positioned right after the
+ * value when possible. A *bufio.Reader is read from
+ * directly, an io.Seeker is seeked back by the bytes
+ * read past the value and an io.ByteScanner is read
+ * one byte at a time. Bytes read past the value from
+ * other readers are lost
+ */
+func newValueReaderParser(r io.Reader, opts ParseOptions) *parser {
+	switch src := r.(type) {
+	case *bufio.Reader:
+
+	case io.Seeker:
+		p := newReaderParser(r, opts)
+		p.src = r
+		return p
+
+	case io.ByteScanner:
+		p := newParser(nil, opts)
+		p.rd = bufio.NewReaderSize(byteReader{src}, 16)
+		p.src = r
+		return p
+	}
+
+	return newReaderParser(r, opts)
+}
+
+/**
+ * Function to hand the bytes read past the current
+ * offset back to the reader the parser was created
+ * with by newValueReaderParser
+ */
+func (p *parser) unread() {
+	p.release()
+
+	n := p.rd.Buffered()
+
+	if p.src == nil || n == 0 {
+		return
+	}
+
+	switch src := p.src.(type) {
+	case io.Seeker:
+		src.Seek(-int64(n), io.SeekCurrent)
+
+	case io.ByteScanner:
+		/*
+		 * At most the byte following a number is read
+		 * ahead of the value
+		 */
+		if n == 1 {
+			src.UnreadByte()
+		}
+	}
+}
+
+/**
+ * Function to read the next chunk of the stream into
+ * data. The bytes before the current offset are no
+ * longer needed and are dropped first so memory use
+ * stays bounded by the size of the largest token.
+ * Returns false if no more data could be read
+ */
+func (p *parser) fill() bool {
+	if p.rd == nil || p.rerr != nil {
+		return false
+	}
+
+	/*
+	 * Drop the consumed bytes, keeping track of the
+	 * lines in them for error reporting. This is done
+	 * once they make up half of data, so the cost of
+	 * the copy is amortized over small reads
+	 */
+	if p.pos > 0 && p.pos >= len(p.data)-p.pos {
+		consumed := p.data[:p.pos]
+
+		if n := bytes.Count(consumed, []byte{'\n'}); n > 0 {
+			p.line += n
+			p.lineStart = p.base + int64(bytes.LastIndexByte(consumed, '\n')) + 1
+		}
+
+		p.base += int64(p.pos)
+		p.data = p.data[:copy(p.data, p.data[p.pos:])]
+		p.pos = 0
+	}
+
+	/*
+	 * The previous chunk was copied in full, release it
+	 * from the reader and peek at the next one. Bytes
+	 * are only discarded from the reader once they are
+	 * consumed, so a caller supplied *bufio.Reader is
+	 * left positioned right after the parsed value
+	 */
+	p.rd.Discard(p.pending)
+	p.pending = 0
+
+	if p.rd.Buffered() == 0 {
+		if _, err := p.rd.Peek(1); err != nil {
+			p.rerr = err
+			return false
+		}
+	}
+
+	size := p.rd.Buffered()
+
+	if p.opts.MaxInputBytes > 0 {
+		allowed := int64(p.opts.MaxInputBytes) - p.base - int64(len(p.data))
+
+		if allowed <= 0 {
+			p.rerr = p.limitError(len(p.data),
+				fmt.Sprintf("input exceeds max size of %d bytes", p.opts.MaxInputBytes))
+			return false
+		}
+
+		if int64(size) > allowed {
+			size = int(allowed)
+		}
+	}
+
+	chunk, _ := p.rd.Peek(size)
+	p.data = append(p.data, chunk...)
+	p.pending = len(chunk)
+
+	return true
+}
+
+/**
+ * Function to release the consumed part of the last
+ * chunk from the reader, leaving the reader positioned
+ * at the current offset. The parser can continue to be
+ * used afterwards
+ */
+func (p *parser) release() {
+	if p.rd == nil {
+		return
+	}
+
+	if n := p.pending - (len(p.data) - p.pos); n > 0 {
+		p.rd.Discard(n)
+		p.pending -= n
+	}
+}
+
+/*
+ * Function to parse a single JSON value from r. The
+ * input is read in chunks and parsing stops right
+ * after the end of the value. The bytes following the
+ * value remain available in r if it is a *bufio.Reader,
+ * an io.Seeker such as *os.File, *strings.Reader or
+ * *bytes.Reader, or an io.ByteScanner. Other readers
+ * are read ahead in chunks, wrap them in a *bufio.Reader
+ * to keep the rest of the stream.
+ * io.EOF is returned if r holds only whitespace
+ */
+func GoJSONParseReader(r io.Reader) (*GoJSON, error) {
+	return GoJSONParseReaderWithOptions(r, ParseOptions{})
+}
+
+/*
+ * Function to parse a single JSON value from r
+ * applying the limits and policies in opts.
+ * MaxInputBytes limits the bytes read from r
+ */
+func GoJSONParseReaderWithOptions(r io.Reader, opts ParseOptions) (*GoJSON, error) {
+	var g *GoJSON
+
+	g = new(GoJSON)
+	p := newValueReaderParser(r, opts)
+	defer p.unread()
+
+	if p.nextToken() == false {
+		if p.rerr == io.EOF {
+			return nil, io.EOF
+		}
+
+		return nil, p.endOfInput(p.pos, "value")
+	}
+
+	if err := p.parseValue(g); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
@@ -1,9 +1,11 @@
 package jsonez
 
 import (
-	"errors"
 	"fmt"
+	"math"
+	"reflect"
 	"runtime"
+	"strconv"
 )
 
 /**
@@ -19,6 +21,34 @@ func funcName() string {
 	return runtime.FuncForPC(pc).Name()
 }
 
+/**
+ * Function to get the name of a JSON type
+ */
+func typeName(t int) string {
+	switch t {
+	case JSON_BOOL:
+		return "JSON_BOOL"
+	case JSON_NULL:
+		return "JSON_NULL"
+	case JSON_INT:
+		return "JSON_INT"
+	case JSON_UINT:
+		return "JSON_UINT"
+	case JSON_DOUBLE:
+		return "JSON_DOUBLE"
+	case JSON_STRING:
+		return "JSON_STRING"
+	case JSON_ARRAY:
+		return "JSON_ARRAY"
+	case JSON_OBJECT:
+		return "JSON_OBJECT"
+	case JSON_NUMBER:
+		return "JSON_NUMBER"
+	}
+
+	return "JSON_UNKNOWN(" + strconv.Itoa(t) + ")"
+}
+
 /**
  * Function to create a null object
  */
@@ -79,6 +109,102 @@ func AllocNumber(val float64, numtype int) *GoJSON {
 	return child
 }
 
+/**
+ * Function to create a GoJSON number object holding
+ * the number literal lit, which must be a valid JSON
+ * number
+ */
+func AllocNumberLiteral(lit string) (*GoJSON, error) {
+	p := newParser([]byte(lit), ParseOptions{})
+
+	if n, _, err := p.scanNumber(); err != nil {
+		return nil, err
+	} else if n != len(lit) {
+		return nil, p.errorAt(n, "invalid character "+quoteChar(lit[n])+" in number", "")
+	}
+
+	child := new(GoJSON)
+	child.Valnum = lit
+	child.Jsontype = JSON_NUMBER
+
+	return child, nil
+}
+
+/**
+ * Function to create a GoJSON object holding an int,
+ * uint, double, bool or string value
+ */
+func allocValue(val interface{}) (*GoJSON, error) {
+	t, err := resolveInterface(val)
+	if err != nil {
+		return nil, err
+	}
+
+	child := new(GoJSON)
+	v := reflect.ValueOf(val)
+
+	switch t {
+	case JSON_INT:
+		if v.Int() < 0 {
+			child.Valint = v.Int()
+			child.Jsontype = JSON_INT
+		} else {
+			child.Valuint = uint64(v.Int())
+			child.Jsontype = JSON_UINT
+		}
+	case JSON_UINT:
+		child.Valuint = v.Uint()
+		child.Jsontype = JSON_UINT
+	case JSON_DOUBLE:
+		child.Valdouble = v.Float()
+		child.Jsontype = JSON_DOUBLE
+	case JSON_BOOL:
+		child.Valbool = v.Bool()
+		child.Jsontype = JSON_BOOL
+	case JSON_STRING:
+		child.Valstr = v.String()
+		child.Jsontype = JSON_STRING
+	}
+
+	return child, nil
+}
+
+/**
+ * Function to get the value of any int or uint type as
+ * an int64. ok is false for other types and for values
+ * beyond the int64 range
+ */
+func intValue(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() <= math.MaxInt64 {
+			return int64(v.Uint()), true
+		}
+	}
+
+	return 0, false
+}
+
+/**
+ * Function to get the value of any int or uint type as
+ * a uint64. ok is false for other types and for
+ * negative values
+ */
+func uintValue(v reflect.Value) (uint64, bool) {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() >= 0 {
+			return uint64(v.Int()), true
+		}
+	}
+
+	return 0, false
+}
+
 /**
  * Function to create a GoJSON array object
  */
@@ -137,14 +263,16 @@ func resolveInterface(v interface{}) (int, error) {
 	case uint64:
 		return JSON_UINT, nil
 	case float64:
+		if f := v.(float64); math.IsNaN(f) || math.IsInf(f, 0) {
+			return -1, fmt.Errorf("jsonez: %w: %v can't be represented in JSON", ErrNumberRange, v)
+		}
 		return JSON_DOUBLE, nil
 	case bool:
 		return JSON_BOOL, nil
 	case string:
 		return JSON_STRING, nil
 	default:
-		errorStr := fmt.Sprintf("%s: Unknown data type", funcName())
-		return -1, errors.New(errorStr)
+		return -1, fmt.Errorf("jsonez: %w %T", ErrUnsupportedType, v)
 	}
 
 }
@@ -0,0 +1,279 @@
+package jsonez
+
+import (
+	"errors"
+	"io"
+)
+
+/**
+ * Event driven parsing. The input is scanned with the
+ * same parser used to build GoJSON trees, but instead
+ * of building a tree the values are reported to a
+ * Handler as they are found
+ */
+
+/*
+ * Handler receives the parse events. Returning an error
+ * from any of the methods stops the walk and the error
+ * is returned to the caller
+ */
+type Handler interface {
+	/**
+	 * Called at the start of an object or array. Returning
+	 * SkipValue skips the whole object or array, and no
+	 * further events are reported for it
+	 */
+	StartObject() error
+	StartArray() error
+
+	/** Called at the end of an object or array */
+	EndObject() error
+	EndArray() error
+
+	/**
+	 * Called with the key of each object entry. Returning
+	 * SkipValue skips the value of the entry
+	 */
+	Key(key string) error
+
+	String(val string) error
+
+	/**
+	 * Called with the number parsed into val. val is
+	 * reused and is only valid during the call
+	 */
+	Number(val *GoJSON) error
+
+	Bool(val bool) error
+	Null() error
+}
+
+/*
+ * SkipValue is returned by a Handler to skip the
+ * current object, array or entry value
+ */
+var SkipValue = errors.New("skip this value")
+
+/**
+ * Handler which ignores all events, used to skip values
+ */
+type skipHandler struct{}
+
+func (skipHandler) StartObject() error   { return nil }
+func (skipHandler) StartArray() error    { return nil }
+func (skipHandler) EndObject() error     { return nil }
+func (skipHandler) EndArray() error      { return nil }
+func (skipHandler) Key(string) error     { return nil }
+func (skipHandler) String(string) error  { return nil }
+func (skipHandler) Number(*GoJSON) error { return nil }
+func (skipHandler) Bool(bool) error      { return nil }
+func (skipHandler) Null() error          { return nil }
+
+/**
+ * Function to skip the next value after validating it
+ */
+func (p *parser) skipValue() error {
+	return p.walkValue(skipHandler{})
+}
+
+/**
+ * Function to walk an array
+ */
+func (p *parser) walkArray(h Handler) error {
+	var count int
+
+	if err := p.enter(); err != nil {
+		return err
+	}
+	p.pos++
+
+	/*
+	 * When skipping, the rest of the array is
+	 * scanned without reporting any events
+	 */
+	if err := h.StartArray(); err == SkipValue {
+		h = skipHandler{}
+	} else if err != nil {
+		return err
+	}
+
+	if p.nextToken() == false {
+		return p.unexpected("value or ']'")
+	}
+
+	if p.data[p.pos] != ']' {
+		for {
+			count++
+
+			if err := p.checkMembers(count); err != nil {
+				return err
+			}
+
+			if err := p.walkValue(h); err != nil {
+				return err
+			}
+
+			if p.nextToken() == false {
+				return p.unexpected("',' or ']'")
+			}
+
+			if p.data[p.pos] != ',' {
+				break
+			}
+			p.pos++
+		}
+
+		if p.data[p.pos] != ']' {
+			return p.unexpected("',' or ']'")
+		}
+	}
+
+	p.pos++
+	p.depth--
+
+	return h.EndArray()
+}
+
+/**
+ * Function to walk an object
+ */
+func (p *parser) walkObject(h Handler) error {
+	var count int
+	var key GoJSON
+
+	if err := p.enter(); err != nil {
+		return err
+	}
+	p.pos++
+
+	/*
+	 * When skipping, the rest of the object is
+	 * scanned without reporting any events
+	 */
+	if err := h.StartObject(); err == SkipValue {
+		h = skipHandler{}
+	} else if err != nil {
+		return err
+	}
+
+	if p.nextToken() == false {
+		return p.unexpected("string or '}'")
+	}
+
+	if p.data[p.pos] != '}' {
+		for {
+			count++
+
+			if err := p.checkMembers(count); err != nil {
+				return err
+			}
+
+			if err := p.parseKey(&key); err != nil {
+				return err
+			}
+
+			if err := h.Key(key.Key); err == SkipValue {
+				if err = p.skipValue(); err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			} else if err = p.walkValue(h); err != nil {
+				return err
+			}
+
+			if p.nextToken() == false {
+				return p.unexpected("',' or '}'")
+			}
+
+			if p.data[p.pos] != ',' {
+				break
+			}
+			p.pos++
+		}
+
+		if p.data[p.pos] != '}' {
+			return p.unexpected("',' or '}'")
+		}
+	}
+
+	p.pos++
+	p.depth--
+
+	return h.EndObject()
+}
+
+/**
+ * Function to walk the current value and report it to h
+ */
+func (p *parser) walkValue(h Handler) error {
+	var val GoJSON
+
+	if p.nextToken() == false {
+		return p.unexpected("value")
+	}
+
+	switch c := p.data[p.pos]; {
+	case c == '[':
+		return p.walkArray(h)
+
+	case c == '{':
+		return p.walkObject(h)
+
+	case c == '"':
+		if err := p.parseString(&val); err != nil {
+			return err
+		}
+		return h.String(val.Valstr)
+	}
+
+	if err := p.parseValue(&val); err != nil {
+		return err
+	}
+
+	switch val.Jsontype {
+	case JSON_NULL:
+		return h.Null()
+	case JSON_BOOL:
+		return h.Bool(val.Valbool)
+	}
+
+	return h.Number(&val)
+}
+
+/*
+ * Function to walk the JSON input and report each value
+ * to h without building a GoJSON tree. The input must be
+ * a single JSON value as with GoJSONParse
+ */
+func GoJSONWalk(input []byte, h Handler) error {
+	p := newParser(input, ParseOptions{})
+
+	if err := p.walkValue(h); err != nil {
+		return err
+	}
+
+	return p.checkEnd()
+}
+
+/*
+ * Function to walk the first JSON value read from r.
+ * Memory use is bounded by the largest string or number
+ * in the input rather than the size of the input. As
+ * with GoJSONParseReader reading stops after the value
+ * and io.EOF is returned if r holds only whitespace
+ */
+func GoJSONWalkReader(r io.Reader, h Handler) error {
+	p := newValueReaderParser(r, ParseOptions{})
+	defer p.unread()
+
+	if p.nextToken() == false {
+		if p.rerr == io.EOF {
+			return io.EOF
+		}
+
+		return p.endOfInput(p.pos, "value")
+	}
+
+	return p.walkValue(h)
+}